@@ -6,7 +6,7 @@ require (
 	github.com/Nerzal/gocloak v0.0.0-20190601232827-0b08578412b7
 	github.com/coreos/go-oidc v2.1.0+incompatible
 	github.com/emirpasic/gods v1.12.0
-	github.com/go-sql-driver/mysql v1.4.1 // indirect
+	github.com/go-sql-driver/mysql v1.4.1
 	github.com/gobuffalo/envy v1.8.1 // indirect
 	github.com/gobuffalo/packr v0.0.0-20191004140626-4b4a3c432a2e
 	github.com/golang/protobuf v1.3.2 // indirect
@@ -23,13 +23,15 @@ require (
 	github.com/spidernest-go/logger v0.0.0-20191128190838-520d89ea00af
 	github.com/spidernest-go/migrate v0.0.0-20190604214622-8fccd3022231
 	github.com/spidernest-go/mux v0.0.0-20201128044825-fb21d0a8ad81
+	github.com/valyala/fasthttp v1.8.0
 	github.com/valyala/fasttemplate v1.1.0 // indirect
 	golang.org/x/crypto v0.0.0-20191219195013-becbf705a915 // indirect
+	golang.org/x/image v0.0.0-20191206065243-da761ea9ff43
 	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
 	golang.org/x/oauth2 v0.0.0-20191202225959-858c2ad4c8b6
 	golang.org/x/sys v0.0.0-20191223224216-5a3cf8467b4e // indirect
 	google.golang.org/appengine v1.6.5 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
 	gopkg.in/resty.v1 v1.12.0 // indirect
-	gopkg.in/square/go-jose.v2 v2.4.1 // indirect
+	gopkg.in/square/go-jose.v2 v2.4.1
 )
@@ -0,0 +1,166 @@
+package achievements
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/gobuffalo/packr"
+	"github.com/spidernest-go/logger"
+)
+
+// Stats a rule can require a minimum of.
+const (
+	StatLevel             = "level"
+	StatPlayCount         = "play_count"
+	StatTotalScore        = "total_score"
+	StatPerformanceRating = "performance_rating"
+	StatMastery           = "mastery"
+	StatClears            = "clears"
+	StatFullCombos        = "full_combos"
+	StatPerfects          = "perfects"
+	StatStreak            = "streak"
+)
+
+var stats = []string{
+	StatLevel, StatPlayCount, StatTotalScore, StatPerformanceRating, StatMastery,
+	StatClears, StatFullCombos, StatPerfects, StatStreak,
+}
+
+// Rule is met once Stat reaches Min.
+type Rule struct {
+	Stat string `json:"stat"`
+	Min  uint64 `json:"min"`
+}
+
+// Achievement is an entry of the catalog, hidden ones are only listed once
+// they are unlocked.
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Rule        Rule   `json:"rule"`
+	Hidden      bool   `json:"hidden,omitempty"`
+}
+
+type Catalog []Achievement
+
+// Stats are the values of a profile rules are evaluated against.
+type Stats map[string]uint64
+
+// Active is the catalog everything is evaluated against.
+var Active Catalog
+
+var ErrInvalidCatalog = errors.New("Achievement catalog is invalid.")
+
+// Load reads the catalog from the JSON file at ACHIEVEMENTS_CATALOG, or
+// the one built into the service.
+func Load() {
+	var (
+		data []byte
+		err  error
+	)
+	if path := os.Getenv("ACHIEVEMENTS_CATALOG"); path != "" {
+		data, err = ioutil.ReadFile(path)
+	} else {
+		data, err = packr.NewBox("./catalog").Find("catalog.json")
+	}
+	if err != nil {
+		logger.Fatal().
+			Err(err).
+			Msg("Achievement catalog could not be read.")
+	}
+
+	c, err := Parse(data)
+	if err != nil {
+		logger.Fatal().
+			Err(err).
+			Msg("Achievement catalog could not be loaded.")
+	}
+	Active = c
+}
+
+// Parse decodes a catalog and checks that ids are unique and every rule
+// requires a known stat.
+func Parse(data []byte) (Catalog, error) {
+	c := *new(Catalog)
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(c))
+	for _, a := range c {
+		if a.ID == "" || ids[a.ID] || !known(a.Rule.Stat) {
+			return nil, ErrInvalidCatalog
+		}
+		ids[a.ID] = true
+	}
+	return c, nil
+}
+
+func known(stat string) bool {
+	for _, s := range stats {
+		if s == stat {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Catalog) Find(id string) *Achievement {
+	for i := range c {
+		if c[i].ID == id {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// Needs reports whether any achievement that isn't unlocked yet depends on
+// stat, so stats that are costly to gather can be skipped.
+func (c Catalog) Needs(stat string, unlocked map[string]bool) bool {
+	for _, a := range c {
+		if !unlocked[a.ID] && a.Rule.Stat == stat {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the achievements that aren't unlocked yet but whose rule
+// s meets.
+func (c Catalog) Evaluate(s Stats, unlocked map[string]bool) []Achievement {
+	earned := []Achievement{}
+	for _, a := range c {
+		if unlocked[a.ID] {
+			continue
+		}
+		if v, ok := s[a.Rule.Stat]; ok && v >= a.Rule.Min {
+			earned = append(earned, a)
+		}
+	}
+	return earned
+}
+
+// Streak counts the consecutive days ending today or yesterday that were
+// played on, days must be UTC days sorted newest first.
+func Streak(days []time.Time, now time.Time) uint64 {
+	y, m, d := now.UTC().Date()
+	next := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if len(days) > 0 && days[0].Before(next) {
+		// a streak isn't broken until a whole day passed without playing
+		next = next.AddDate(0, 0, -1)
+	}
+
+	var n uint64
+	for _, day := range days {
+		if !day.Equal(next) {
+			break
+		}
+		n++
+		next = next.AddDate(0, 0, -1)
+	}
+	return n
+}
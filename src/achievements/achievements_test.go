@@ -0,0 +1,110 @@
+package achievements
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("catalog/catalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(data); err != nil {
+		t.Errorf("built in catalog: %v", err)
+	}
+
+	for name, data := range map[string]string{
+		"duplicate id": `[
+			{"id": "a", "rule": {"stat": "level", "min": 1}},
+			{"id": "a", "rule": {"stat": "play_count", "min": 1}}
+		]`,
+		"unknown stat": `[{"id": "a", "rule": {"stat": "high_fives", "min": 1}}]`,
+		"missing id":   `[{"rule": {"stat": "level", "min": 1}}]`,
+	} {
+		if _, err := Parse([]byte(data)); err != ErrInvalidCatalog {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalidCatalog)
+		}
+	}
+	if _, err := Parse([]byte(`{`)); err == nil {
+		t.Error("malformed JSON was accepted")
+	}
+}
+
+var testCatalog = Catalog{
+	{ID: "level-10", Rule: Rule{StatLevel, 10}},
+	{ID: "plays-1", Rule: Rule{StatPlayCount, 1}},
+	{ID: "plays-100", Rule: Rule{StatPlayCount, 100}},
+	{ID: "streak-7", Rule: Rule{StatStreak, 7}},
+}
+
+func TestEvaluate(t *testing.T) {
+	s := Stats{StatLevel: 10, StatPlayCount: 99}
+	earned := testCatalog.Evaluate(s, map[string]bool{"plays-1": true})
+	if len(earned) != 1 || earned[0].ID != "level-10" {
+		t.Errorf("got %v, want only level-10", earned)
+	}
+
+	// stats that weren't gathered never earn anything, not even a 0 minimum
+	c := Catalog{{ID: "zero", Rule: Rule{StatClears, 0}}}
+	if earned := c.Evaluate(Stats{}, nil); len(earned) != 0 {
+		t.Errorf("got %v for a missing stat", earned)
+	}
+	if earned := c.Evaluate(Stats{StatClears: 0}, nil); len(earned) != 1 {
+		t.Errorf("got %v, want zero", earned)
+	}
+}
+
+func TestNeeds(t *testing.T) {
+	if !testCatalog.Needs(StatStreak, nil) {
+		t.Error("streak isn't needed with nothing unlocked")
+	}
+	if testCatalog.Needs(StatStreak, map[string]bool{"streak-7": true}) {
+		t.Error("streak is needed once every streak achievement is unlocked")
+	}
+	if !testCatalog.Needs(StatPlayCount, map[string]bool{"plays-1": true}) {
+		t.Error("play count isn't needed while plays-100 is locked")
+	}
+	if testCatalog.Needs(StatPerfects, nil) {
+		t.Error("perfects are needed without any achievement depending on them")
+	}
+}
+
+func TestStreak(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, tc := range []struct {
+		name string
+		days []time.Time
+		want uint64
+	}{
+		{"never played", nil, 0},
+		{"played today", []time.Time{day(10)}, 1},
+		{"played today and before", []time.Time{day(10), day(9), day(8)}, 3},
+		{"played yesterday", []time.Time{day(9), day(8)}, 2},
+		{"last played two days ago", []time.Time{day(8), day(7)}, 0},
+		{"gap", []time.Time{day(10), day(9), day(7), day(6)}, 2},
+		{"last played a week ago", []time.Time{day(2), day(1)}, 0},
+	} {
+		if got := Streak(tc.days, now); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	// the month boundary itself doesn't break a streak
+	march1 := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	days := []time.Time{day(1), time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)}
+	if got := Streak(days, march1); got != 2 {
+		t.Errorf("across a month: got %d, want 2", got)
+	}
+
+	// today is the UTC day, whatever zone now is in
+	late := time.Date(2026, 3, 10, 23, 30, 0, 0, time.FixedZone("", -5*3600))
+	if got := Streak([]time.Time{day(11), day(10)}, late); got != 2 {
+		t.Errorf("in another zone: got %d, want 2", got)
+	}
+}
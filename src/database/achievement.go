@@ -0,0 +1,98 @@
+package database
+
+import (
+	"time"
+
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/logger"
+)
+
+// Unlock records when a profile unlocked an achievement of the catalog.
+type Unlock struct {
+	ProfileID   uint64    `db:"profile_id" json:"-"`
+	Achievement string    `db:"achievement" json:"id"`
+	Unlocked    time.Time `db:"unlocked" json:"unlocked"`
+}
+
+// SelectUnlocks returns the achievements a profile unlocked, oldest first.
+func SelectUnlocks(profile uint64) ([]Unlock, error) {
+	us := *new([]Unlock)
+	err := db.SelectFrom("achievements_unlocked").
+		Where("profile_id = ?", profile).
+		OrderBy("unlocked", "achievement").
+		All(&us)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Achievements of profile %d could not be selected.", profile)
+		return nil, err
+	}
+	return us, nil
+}
+
+// SaveUnlock stores u, an achievement that was already unlocked keeps its
+// original timestamp.
+func SaveUnlock(u *Unlock) error {
+	_, err := db.InsertInto("achievements_unlocked").
+		Values(u).
+		Amend(func(q string) string {
+			return q + " ON DUPLICATE KEY UPDATE `unlocked` = `unlocked`"
+		}).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Achievement %s of profile %d could not be stored.", u.Achievement, u.ProfileID)
+	}
+	return err
+}
+
+// BoardCounts returns on how many boards the profile at least once cleared,
+// full combo'd or played perfectly, across every mode.
+func BoardCounts(profile uint64) (cleared, fullCombo, perfect uint64, err error) {
+	rs, err := boardResults(db, profile, "")
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Board results of profile %d could not be selected.", profile)
+		return 0, 0, 0, err
+	}
+	for _, r := range rs {
+		if r.Cleared {
+			cleared++
+		}
+		if r.FullCombo {
+			fullCombo++
+		}
+		if r.Perfect {
+			perfect++
+		}
+	}
+	return cleared, fullCombo, perfect, nil
+}
+
+// PlayDays returns the days since t the profile played on, newest first.
+func PlayDays(profile uint64, t time.Time) ([]time.Time, error) {
+	rows := *new([]struct {
+		Day time.Time `db:"day"`
+	})
+	err := db.Select(upper.Raw("DATE(played) AS day")).
+		Distinct().
+		From("plays").
+		Where("profile_id = ? AND played >= ?", profile, Day(t)).
+		OrderBy("-day").
+		All(&rows)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Play days of profile %d could not be selected.", profile)
+		return nil, err
+	}
+
+	days := make([]time.Time, len(rows))
+	for i, r := range rows {
+		y, m, d := r.Day.Date()
+		days[i] = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return days, nil
+}
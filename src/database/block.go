@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+var (
+	ErrSelfBlock = errors.New("Profiles can not block themselves.")
+	ErrBlocked   = errors.New("Profile is blocked.")
+)
+
+// notBlocking is the condition for profiles p that didn't block the profile
+// in its argument.
+const notBlocking = "p.id NOT IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)"
+
+// Block makes blocker block blocked, any follow between the two is removed.
+// Blocking twice is not an error.
+func Block(blocker, blocked uint64) error {
+	if blocker == blocked {
+		return ErrSelfBlock
+	}
+
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		_, err := tx.InsertInto("blocks").
+			Columns("blocker_id", "blocked_id").
+			Values(blocker, blocked).
+			Amend(func(q string) string {
+				return q + " ON DUPLICATE KEY UPDATE `blocker_id` = `blocker_id`"
+			}).
+			Exec()
+		if err != nil {
+			return err
+		}
+		_, err = tx.DeleteFrom("follows").
+			Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+				blocker, blocked, blocked, blocker).
+			Exec()
+		return err
+	})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profile %d could not block profile %d.", blocker, blocked)
+	}
+	return err
+}
+
+func Unblock(blocker, blocked uint64) error {
+	_, err := db.DeleteFrom("blocks").
+		Where("blocker_id = ? AND blocked_id = ?", blocker, blocked).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profile %d could not unblock profile %d.", blocker, blocked)
+	}
+	return err
+}
+
+// Blocks reports whether blocker blocked blocked.
+func Blocks(blocker, blocked uint64) (bool, error) {
+	n, err := db.Collection("blocks").
+		Find("blocker_id = ? AND blocked_id = ?", blocker, blocked).
+		Count()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Block of profile %d by profile %d could not be looked up.", blocked, blocker)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SelectBlocked returns a page of the profiles the profile blocked, newest
+// first, along with their total.
+func SelectBlocked(profile uint64, page, perPage uint) ([]Connection, uint64, error) {
+	return pageConnections(blocked(profile).Paginate(perPage), page)
+}
+
+// SelectAllBlocked returns every profile the profile blocked.
+func SelectAllBlocked(profile uint64) ([]Connection, error) {
+	cs := *new([]Connection)
+	if err := blocked(profile).All(&cs); err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Blocks of profile %d could not be selected.", profile)
+		return nil, err
+	}
+	return cs, nil
+}
+
+func blocked(profile uint64) sqlbuilder.Selector {
+	return db.Select(append(connectionColumns, upper.Raw("k.created AS since"))...).
+		From("blocks AS k").
+		Join("profiles AS p").On("p.id = k.blocked_id").
+		Where("k.blocker_id = ?", profile).
+		OrderBy("-since", "p.id")
+}
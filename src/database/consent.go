@@ -0,0 +1,82 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+const (
+	ConsentRequested = "requested"
+	ConsentConfirmed = "confirmed"
+	ConsentWithdrawn = "withdrawn"
+)
+
+// Consent is an entry in the append-only record of mailing list consent.
+// Emails are only kept hashed so the record can outlive the entry it is
+// about.
+type Consent struct {
+	ID        uint64    `db:"id,omitempty" json:"-"`
+	EmailHash string    `db:"email_hash" json:"-"`
+	Event     string    `db:"event" json:"event"`
+	At        time.Time `db:"at,omitempty" json:"at"`
+	IP        string    `db:"ip" json:"ip"`
+}
+
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordConsent appends event for email to the consent record.
+func RecordConsent(email, event, ip string) error {
+	_, err := db.InsertInto("reqlist_consent").
+		Values(Consent{EmailHash: hashEmail(email), Event: event, At: time.Now(), IP: ip}).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Consent could not be inserted into the table.")
+	}
+	return err
+}
+
+func SelectConsent(email string) ([]Consent, error) {
+	cs := *new([]Consent)
+	err := db.SelectFrom("reqlist_consent").
+		Where("email_hash = ?", hashEmail(email)).
+		OrderBy("at", "id").
+		All(&cs)
+	return cs, err
+}
+
+// LastConsent returns when event was last recorded for email, or the zero
+// time if it never was.
+func LastConsent(email, event string) (time.Time, error) {
+	cs := *new([]Consent)
+	err := db.SelectFrom("reqlist_consent").
+		Where("email_hash = ? AND event = ?", hashEmail(email), event).
+		OrderBy("-at", "-id").
+		Limit(1).
+		All(&cs)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Consent could not be selected.")
+		return time.Time{}, err
+	}
+	if len(cs) == 0 {
+		return time.Time{}, nil
+	}
+	return cs[0].At, nil
+}
+
+func forgetConsent(tx sqlbuilder.Tx, email string) error {
+	_, err := tx.DeleteFrom("reqlist_consent").
+		Where("email_hash = ?", hashEmail(email)).
+		Exec()
+	return err
+}
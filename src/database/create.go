@@ -1,26 +1,53 @@
 package database
 
 import (
+	"github.com/orchestrafm/profiles/src/progression"
+	upper "github.com/spidernest-go/db"
 	"github.com/spidernest-go/logger"
 )
 
 func (p *Profile) New() error {
 	// TODO: Make sure something doesn't already exist in the spot [id, track_id]
-	r, err := db.InsertInto("profiles").
-		Values(p).
-		Exec()
+	p.Level = progression.Active.Level(p.Experience)
 
-	if err != nil {
-		logger.Error().
-			Err(err).
-			Msg("Profile could not be inserted into the table.")
-	}
+	// ids of deleted profiles must never come back, should the auto increment
+	// counter ever roll back onto a tombstone the row is moved past it
+	for {
+		r, err := db.InsertInto("profiles").
+			Values(p).
+			Exec()
+
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Profile could not be inserted into the table.")
+			return err
+		}
 
-	id, err := r.LastInsertId()
-	if err == nil {
-		p.ID = uint64(id)
+		id, err := r.LastInsertId()
+		if err != nil {
+			return err
+		}
+		err, _ = SelectTombstone(uint64(id))
+		if err == upper.ErrNoMoreRows {
+			p.ID = uint64(id)
+			return nil
+		}
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Tombstone of profile id %d could not be looked up.", id)
+			// without knowing the id is free the row can't be kept
+			db.DeleteFrom("profiles").Where("id = ?", id).Exec()
+			return err
+		}
+
+		logger.Warn().
+			Msgf("Profile id %d belongs to a deleted profile, skipping it.", id)
+		if _, err := db.DeleteFrom("profiles").Where("id = ?", id).Exec(); err != nil {
+			return err
+		}
 	}
-	return err
 }
 
 func (r *ReqList) New() error {
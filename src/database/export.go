@@ -0,0 +1,146 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/spidernest-go/logger"
+)
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportFetched = "fetched"
+)
+
+// ExportTimeout is how long building an archive may take. Pending exports
+// older than that were abandoned, by a restart for instance, and no longer
+// keep the profile from requesting a new one.
+const ExportTimeout = 15 * time.Minute
+
+// Export is a personal data archive being built for, or waiting to be
+// downloaded by, a profile. Only a hash of the download token is kept.
+type Export struct {
+	ID        uint64    `db:"id" json:"-"`
+	ProfileID uint64    `db:"profile_id" json:"-"`
+	TokenHash string    `db:"token_hash" json:"-"`
+	Status    string    `db:"status" json:"status"`
+	Created   time.Time `db:"created,omitempty" json:"created"`
+	Expires   time.Time `db:"expires" json:"expires"`
+}
+
+// Stale reports whether the export was abandoned while being built.
+func (e *Export) Stale() bool {
+	return e.Status == ExportPending && e.Created.Before(time.Now().Add(-ExportTimeout))
+}
+
+// HashExportToken is how download tokens are stored and looked up.
+func HashExportToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (e *Export) New() error {
+	r, err := db.InsertInto("exports").
+		Values(e).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Export could not be inserted into the table.")
+		return err
+	}
+
+	id, err := r.LastInsertId()
+	if err == nil {
+		e.ID = uint64(id)
+	}
+	return err
+}
+
+// SetStatus moves the export from one status to another, false is returned
+// when it was no longer in the from status.
+func (e *Export) SetStatus(from, to string) (bool, error) {
+	r, err := db.Update("exports").
+		Set("status", to).
+		Where("id = ? AND status = ?", e.ID, from).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Export status could not be updated.")
+		return false, err
+	}
+
+	n, err := r.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 1 {
+		e.Status = to
+	}
+	return n == 1, nil
+}
+
+func SelectExportByToken(token string) (error, *Export) {
+	e := *new(Export)
+	err := db.SelectFrom("exports").
+		Where("token_hash = ?", HashExportToken(token)).
+		Limit(1).
+		One(&e)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &e
+}
+
+// SelectActiveExport returns the export of the profile that is still being
+// built or waiting to be downloaded, if any.
+func SelectActiveExport(profile uint64) (error, *Export) {
+	e := *new(Export)
+	now := time.Now()
+	err := db.SelectFrom("exports").
+		Where("profile_id = ? AND ((status = ? AND created > ?) OR (status = ? AND expires > ?))",
+			profile, ExportPending, now.Add(-ExportTimeout), ExportReady, now).
+		Limit(1).
+		One(&e)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &e
+}
+
+// SelectExportsOf lists every export of a profile.
+func SelectExportsOf(profile uint64) ([]Export, error) {
+	es := *new([]Export)
+	err := db.SelectFrom("exports").
+		Where("profile_id = ?", profile).
+		All(&es)
+	return es, err
+}
+
+// SelectExpiredExports lists exports past their expiry, and downloaded,
+// failed or abandoned ones, whose archives can be thrown away.
+func SelectExpiredExports() ([]Export, error) {
+	es := *new([]Export)
+	now := time.Now()
+	err := db.SelectFrom("exports").
+		Where("expires <= ? OR status IN ? OR (status = ? AND created <= ?)",
+			now, []string{ExportFetched, ExportFailed}, ExportPending, now.Add(-ExportTimeout)).
+		All(&es)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Expired exports could not be listed.")
+	}
+	return es, err
+}
+
+func (e *Export) Delete() error {
+	_, err := db.DeleteFrom("exports").
+		Where("id = ?", e.ID).
+		Exec()
+	return err
+}
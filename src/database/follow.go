@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+var ErrSelfFollow = errors.New("Profiles can not follow themselves.")
+
+// Connection is a profile on the other end of a follow, Since is when the
+// follow, or for friends the later of both, was made.
+type Connection struct {
+	ID          uint64    `db:"id" json:"id"`
+	Handle      string    `db:"username" json:"username"`
+	DisplayName string    `db:"display_name" json:"display_name,omitempty"`
+	Country     string    `db:"country" json:"country,omitempty"`
+	Level       uint64    `db:"level" json:"level"`
+	Since       time.Time `db:"since" json:"since"`
+}
+
+var connectionColumns = []interface{}{
+	"p.id", "p.username", "p.display_name", "p.country", "p.level",
+}
+
+// Follow makes follower follow followee, following twice is not an error.
+// Profiles can't follow each other while either blocked the other, the
+// block is checked by the insert itself so a concurrent Block can't be
+// missed.
+func Follow(follower, followee uint64) error {
+	if follower == followee {
+		return ErrSelfFollow
+	}
+
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		r, err := tx.Exec("INSERT INTO `follows` (`follower_id`, `followee_id`)"+
+			" SELECT ?, ? FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM `blocks`"+
+			" WHERE (`blocker_id` = ? AND `blocked_id` = ?) OR (`blocker_id` = ? AND `blocked_id` = ?))"+
+			" ON DUPLICATE KEY UPDATE `follower_id` = `follower_id`",
+			follower, followee, follower, followee, followee, follower)
+		if err != nil {
+			return err
+		}
+		if n, _ := r.RowsAffected(); n > 0 {
+			return nil
+		}
+
+		// nothing was inserted, either the follow exists or a block does
+		n, err := tx.Collection("blocks").
+			Find("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+				follower, followee, followee, follower).
+			Count()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrBlocked
+		}
+		return nil
+	})
+	if err != nil && err != ErrBlocked {
+		logger.Error().
+			Err(err).
+			Msgf("Profile %d could not follow profile %d.", follower, followee)
+	}
+	return err
+}
+
+func Unfollow(follower, followee uint64) error {
+	_, err := db.DeleteFrom("follows").
+		Where("follower_id = ? AND followee_id = ?", follower, followee).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profile %d could not unfollow profile %d.", follower, followee)
+	}
+	return err
+}
+
+// Follows reports whether follower follows followee.
+func Follows(follower, followee uint64) (bool, error) {
+	n, err := db.Collection("follows").
+		Find("follower_id = ? AND followee_id = ?", follower, followee).
+		Count()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Follow of profile %d by profile %d could not be looked up.", followee, follower)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// FollowCounts returns how many profiles follow the profile and how many
+// it follows, they match the totals of SelectFollowers and SelectFollowing
+// for the same viewer.
+func FollowCounts(profile, viewer uint64) (uint64, uint64, error) {
+	ers, err := followers(profile, viewer).Paginate(1).TotalEntries()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Followers of profile %d could not be counted.", profile)
+		return 0, 0, err
+	}
+	ing, err := following(profile, viewer).Paginate(1).TotalEntries()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profiles followed by profile %d could not be counted.", profile)
+		return 0, 0, err
+	}
+	return ers, ing, nil
+}
+
+// SelectFollowers returns a page of the profiles following the profile,
+// newest first, along with their total. Profiles that blocked viewer are
+// left out, unless viewer is 0.
+func SelectFollowers(profile, viewer uint64, page, perPage uint) ([]Connection, uint64, error) {
+	return pageConnections(followers(profile, viewer).Paginate(perPage), page)
+}
+
+// SelectFollowing returns a page of the profiles the profile follows, newest
+// first, along with their total. Profiles that blocked viewer are left out,
+// unless viewer is 0.
+func SelectFollowing(profile, viewer uint64, page, perPage uint) ([]Connection, uint64, error) {
+	return pageConnections(following(profile, viewer).Paginate(perPage), page)
+}
+
+// SelectFriends returns a page of the profiles that follow the profile back,
+// newest friendship first, along with their total. Profiles that blocked
+// viewer are left out, unless viewer is 0.
+func SelectFriends(profile, viewer uint64, page, perPage uint) ([]Connection, uint64, error) {
+	q := db.Select(append(connectionColumns, upper.Raw("GREATEST(f.created, b.created) AS since"))...).
+		From("follows AS f").
+		Join("follows AS b").On("b.follower_id = f.followee_id AND b.followee_id = f.follower_id").
+		Join("profiles AS p").On("p.id = f.followee_id").
+		Where("f.follower_id = ?", profile)
+	return pageConnections(hideBlocking(q, viewer).
+		OrderBy("-since", "p.id").
+		Paginate(perPage), page)
+}
+
+func followers(profile, viewer uint64) sqlbuilder.Selector {
+	q := db.Select(append(connectionColumns, upper.Raw("f.created AS since"))...).
+		From("follows AS f").
+		Join("profiles AS p").On("p.id = f.follower_id").
+		Where("f.followee_id = ?", profile)
+	return hideBlocking(q, viewer).OrderBy("-since", "p.id")
+}
+
+func following(profile, viewer uint64) sqlbuilder.Selector {
+	q := db.Select(append(connectionColumns, upper.Raw("f.created AS since"))...).
+		From("follows AS f").
+		Join("profiles AS p").On("p.id = f.followee_id").
+		Where("f.follower_id = ?", profile)
+	return hideBlocking(q, viewer).OrderBy("-since", "p.id")
+}
+
+func hideBlocking(q sqlbuilder.Selector, viewer uint64) sqlbuilder.Selector {
+	if viewer == 0 {
+		return q
+	}
+	return q.And(notBlocking, viewer)
+}
+
+func pageConnections(p sqlbuilder.Paginator, page uint) ([]Connection, uint64, error) {
+	total, err := p.TotalEntries()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Follows could not be counted.")
+		return nil, 0, err
+	}
+
+	cs := *new([]Connection)
+	if err := p.Page(page).All(&cs); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Follows could not be listed.")
+		return nil, 0, err
+	}
+	return cs, total, nil
+}
+
+// SelectFollowsOf returns every profile following the profile and every
+// profile it follows.
+func SelectFollowsOf(profile uint64) ([]Connection, []Connection, error) {
+	ers, ing := *new([]Connection), *new([]Connection)
+	err := followers(profile, 0).All(&ers)
+	if err == nil {
+		err = following(profile, 0).All(&ing)
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Follows of profile %d could not be selected.", profile)
+		return nil, nil, err
+	}
+	return ers, ing, nil
+}
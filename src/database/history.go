@@ -0,0 +1,110 @@
+package database
+
+import (
+	"time"
+
+	"github.com/spidernest-go/logger"
+)
+
+// Snapshot is a profile's standing at the end of a day.
+type Snapshot struct {
+	ProfileID         uint64    `db:"profile_id" json:"-"`
+	Day               time.Time `db:"day" json:"-"`
+	Date              string    `db:"-" json:"date"`
+	PerformanceRating uint64    `db:"performance_rating" json:"performance_rating"`
+	GlobalRank        uint64    `db:"global_rank" json:"global_rank"`
+	Level             uint64    `db:"level" json:"level"`
+	PlayCount         uint64    `db:"play_count" json:"play_count"`
+}
+
+// Day truncates t to the UTC day it falls on.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// SaveSnapshot stores s, replacing the snapshot of the same day if there
+// already is one.
+func SaveSnapshot(s *Snapshot) error {
+	_, err := db.InsertInto("profile_history").
+		Values(s).
+		Amend(func(q string) string {
+			return q + " ON DUPLICATE KEY UPDATE" +
+				" `performance_rating` = VALUES(`performance_rating`), `global_rank` = VALUES(`global_rank`)," +
+				" `level` = VALUES(`level`), `play_count` = VALUES(`play_count`)"
+		}).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Snapshot of profile %d could not be stored.", s.ProfileID)
+	}
+	return err
+}
+
+// ActiveProfiles returns the ids of the profiles that played since t.
+func ActiveProfiles(t time.Time) (map[uint64]bool, error) {
+	rows := *new([]struct {
+		ProfileID uint64 `db:"profile_id"`
+	})
+	err := db.Select("profile_id").
+		Distinct().
+		From("plays").
+		Where("played >= ?", t).
+		All(&rows)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Active profiles could not be selected.")
+		return nil, err
+	}
+
+	active := make(map[uint64]bool, len(rows))
+	for _, r := range rows {
+		active[r.ProfileID] = true
+	}
+	return active, nil
+}
+
+// SelectHistory returns the snapshots of a profile from the day from up to
+// and including the day to, oldest first.
+func SelectHistory(profile uint64, from, to time.Time) ([]Snapshot, error) {
+	ss := *new([]Snapshot)
+	err := db.SelectFrom("profile_history").
+		Where("profile_id = ? AND day >= ? AND day <= ?", profile, Day(from), Day(to)).
+		OrderBy("day").
+		All(&ss)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("History of profile %d could not be selected.", profile)
+		return nil, err
+	}
+	for i := range ss {
+		ss[i].Date = ss[i].Day.Format("2006-01-02")
+	}
+	return ss, nil
+}
+
+// DownsampleHistory keeps only the last snapshot of every week for days
+// before weekly, and of every month for days before monthly.
+func DownsampleHistory(weekly, monthly time.Time) error {
+	_, err := db.Exec("DELETE `h` FROM `profile_history` AS `h` JOIN `profile_history` AS `k`"+
+		" ON `k`.`profile_id` = `h`.`profile_id` AND YEARWEEK(`k`.`day`, 3) = YEARWEEK(`h`.`day`, 3) AND `k`.`day` > `h`.`day`"+
+		" WHERE `h`.`day` < ?", Day(weekly))
+	if err == nil {
+		_, err = db.Exec("DELETE `h` FROM `profile_history` AS `h` JOIN `profile_history` AS `k`"+
+			" ON `k`.`profile_id` = `h`.`profile_id` AND EXTRACT(YEAR_MONTH FROM `k`.`day`) = EXTRACT(YEAR_MONTH FROM `h`.`day`) AND `k`.`day` > `h`.`day`"+
+			" WHERE `h`.`day` < ?", Day(monthly))
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("History could not be downsampled.")
+	}
+	return err
+}
+
+func SelectAllHistory(profile uint64) ([]Snapshot, error) {
+	return SelectHistory(profile, time.Time{}, time.Now())
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/spidernest-go/logger"
 )
@@ -23,3 +24,74 @@ func SelectProfileById(id uint64) (error, *Profile) {
 
 	return nil, &pf
 }
+
+func SelectProfileByUUID(uuid string) (error, *Profile) {
+	pf := *new(Profile)
+	err := db.SelectFrom("profiles").
+		Where("uuid = ?", uuid).
+		Limit(1).
+		One(&pf)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("SQL Execution had an issue when executing.")
+		return err, nil
+	}
+
+	return nil, &pf
+}
+
+func SelectProfileByUsername(username string) (error, *Profile) {
+	pf := *new(Profile)
+	err := db.SelectFrom("profiles").
+		Where("username = ?", strings.ToLower(username)).
+		Limit(1).
+		One(&pf)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("SQL Execution had an issue when executing.")
+		return err, nil
+	}
+
+	return nil, &pf
+}
+
+// SetHandle stores the identity provider username of the profile so it can
+// be looked up by name without asking the identity provider.
+func (p *Profile) SetHandle(username string) error {
+	p.Handle = strings.ToLower(username)
+	_, err := db.Update("profiles").
+		Set("username", p.Handle).
+		Where("id = ?", p.ID).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Profile username could not be updated.")
+	}
+	return err
+}
+
+// SelectProfilesWithoutHandle returns up to n profiles after id that have
+// no username cached yet.
+func SelectProfilesWithoutHandle(after uint64, n int) ([]Profile, error) {
+	ps := *new([]Profile)
+	err := db.SelectFrom("profiles").
+		Where("username = '' AND id > ?", after).
+		OrderBy("id").
+		Limit(n).
+		All(&ps)
+	return ps, err
+}
+
+// SelectProfiles returns up to n profiles after id.
+func SelectProfiles(after uint64, n int) ([]Profile, error) {
+	ps := *new([]Profile)
+	err := db.SelectFrom("profiles").
+		Where("id > ?", after).
+		OrderBy("id").
+		Limit(n).
+		All(&ps)
+	return ps, err
+}
@@ -1,69 +1,230 @@
 package database
 
 import (
-	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
 	"github.com/spidernest-go/logger"
 )
 
+// Invite is a registration code, it is burned once it was redeemed MaxUses
+// times and can no longer be redeemed after Expires.
 type Invite struct {
-	ID     uint64 `db:"id"`
-	Code   string `db:"code"`
-	Burned bool   `db:"burned"`
+	ID       uint64     `db:"id" json:"id"`
+	Code     string     `db:"code" json:"code"`
+	Burned   bool       `db:"burned" json:"burned"`
+	Expires  *time.Time `db:"expires" json:"expires,omitempty"`
+	MaxUses  uint64     `db:"max_uses" json:"max_uses"`
+	Uses     uint64     `db:"uses" json:"uses"`
+	IssuerID *uint64    `db:"issuer_id" json:"issuer_id,omitempty"`
+	Created  time.Time  `db:"created,omitempty" json:"created"`
 }
 
-func BurnInvite(code string) error {
-	invites := db.Collection("invites")
-	//rs := invites.Find(code) // BUG: This doesn't work, not sure why
-	rs := invites.Find("code", code)
-	i := *new(Invite)
-	err := rs.One(&i)
-	if err != nil && err != sql.ErrNoRows {
-		logger.Error().
-			Err(err).
-			Msg("Bad parameters or database error.")
+// Redemption records a profile registering with an invite code.
+type Redemption struct {
+	InviteID  uint64    `db:"invite_id" json:"invite_id"`
+	ProfileID uint64    `db:"profile_id" json:"profile_id"`
+	Redeemed  time.Time `db:"redeemed,omitempty" json:"redeemed"`
+}
 
-		return err
+// InviteCodeLength is the length of the invites.code column.
+const InviteCodeLength = 6
+
+var (
+	ErrInviteBurned   = errors.New("Invite code was already burned.")
+	ErrInviteExpired  = errors.New("Invite code has expired.")
+	ErrInviteNotFound = errors.New("Invite code does not exist.")
+)
+
+// NewInvites mints count invite codes drawn from gen, each taking its
+// expiry, uses and issuer from tmpl. Codes that collide with existing ones
+// are drawn again.
+func NewInvites(count int, tmpl Invite, gen func() (string, error)) ([]Invite, error) {
+	if tmpl.MaxUses == 0 {
+		tmpl.MaxUses = 1
 	}
 
-	if i.Burned == true {
-		logger.Warn().
-			Msg("Invite Code was already burned.")
+	invs := make([]Invite, 0, count)
+	for len(invs) < count {
+		code, err := gen()
+		if err != nil {
+			return invs, err
+		}
+
+		i := tmpl
+		i.Code = code
+		i.Burned = false
+		i.Uses = 0
+		i.Created = time.Now()
+		r, err := db.InsertInto("invites").
+			Columns("code", "expires", "max_uses", "issuer_id", "created").
+			Values(i.Code, i.Expires, i.MaxUses, i.IssuerID, i.Created).
+			Exec()
+		if isDuplicate(err) {
+			continue
+		} else if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Invite code could not be inserted into the table.")
+			return invs, err
+		}
+
+		id, err := r.LastInsertId()
+		if err != nil {
+			return invs, err
+		}
+		i.ID = uint64(id)
+		invs = append(invs, i)
+	}
+	return invs, nil
+}
 
-		return errors.New("Invite code was already burned.")
+// ListInvites returns a page (starting from 1) of invite codes along with the
+// total number of codes matching, burned filters on the burned state when
+// it is not nil.
+func ListInvites(burned *bool, page, perPage uint) ([]Invite, uint64, error) {
+	q := db.SelectFrom("invites").OrderBy("id")
+	if burned != nil {
+		q = q.Where("burned = ?", *burned)
 	}
-	i.Burned = true
-	err = rs.Update(i)
+
+	p := q.Paginate(perPage)
+	total, err := p.TotalEntries()
 	if err != nil {
 		logger.Error().
 			Err(err).
-			Msg("Invite Code could not be updated from the table.")
+			Msg("Invite codes could not be counted.")
+		return nil, 0, err
 	}
-	return err
-}
 
-func UnburnInvite(code string) error {
-	invites := db.Collection("invites")
-	rs := invites.Find("code", code)
-	i := *new(Invite)
-	err := rs.One(&i)
-	if err != nil && err != sql.ErrNoRows {
+	invs := *new([]Invite)
+	if err := p.Page(page).All(&invs); err != nil {
 		logger.Error().
 			Err(err).
-			Msg("Bad parameters or database error.")
+			Msg("Invite codes could not be listed.")
+		return nil, 0, err
+	}
+	return invs, total, nil
+}
 
+// RevokeInvite deletes an invite code that has not been used yet.
+func RevokeInvite(code string) error {
+	r, err := db.DeleteFrom("invites").
+		Where("code = ? AND uses = 0", code).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invite code could not be deleted from the table.")
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
 		return err
+	} else if n == 1 {
+		return nil
+	}
+
+	if err, _ := SelectInvite(code); err == nil {
+		return ErrInviteBurned
+	}
+	return ErrInviteNotFound
+}
+
+func SelectInvite(code string) (error, *Invite) {
+	i := *new(Invite)
+	err := db.SelectFrom("invites").
+		Where("code = ?", code).
+		Limit(1).
+		One(&i)
+	if err != nil {
+		return err, nil
 	}
+	return nil, &i
+}
+
+// SelectRedemptions lists who redeemed an invite code.
+func (i *Invite) SelectRedemptions() ([]Redemption, error) {
+	rs := *new([]Redemption)
+	err := db.SelectFrom("invite_redemptions").
+		Where("invite_id = ?", i.ID).
+		OrderBy("redeemed").
+		All(&rs)
+	return rs, err
+}
+
+// SelectInvitesIssuedBy lists the invite codes a profile handed out.
+func SelectInvitesIssuedBy(profile uint64) ([]Invite, error) {
+	invs := *new([]Invite)
+	err := db.SelectFrom("invites").
+		Where("issuer_id = ?", profile).
+		OrderBy("id").
+		All(&invs)
+	return invs, err
+}
+
+// SelectRedemptionsBy lists the invite codes a profile registered with.
+func SelectRedemptionsBy(profile uint64) ([]Redemption, error) {
+	rs := *new([]Redemption)
+	err := db.SelectFrom("invite_redemptions").
+		Where("profile_id = ?", profile).
+		All(&rs)
+	return rs, err
+}
+
+func isDuplicate(err error) bool {
+	merr, ok := err.(*mysql.MySQLError)
+	return ok && merr.Number == 1062
+}
 
-	if i.Burned == false {
+// BurnInvite takes one use of an invite code. The check and the update are
+// a single statement so concurrent registrations can't both take the last
+// use of a code.
+func BurnInvite(code string) (*Invite, error) {
+	r, err := db.Update("invites").
+		Set("uses = uses + 1").
+		Set("burned = (uses >= max_uses)").
+		Where("code = ? AND uses < max_uses AND (expires IS NULL OR expires > ?)", code, time.Now()).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invite Code could not be updated from the table.")
+		return nil, err
+	}
+
+	n, err := r.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	err, i := SelectInvite(code)
+	switch {
+	case err != nil:
 		logger.Warn().
-			Msg("Invite code has yet to be used.")
-		return nil
+			Msg("Invite Code does not exist.")
+		return nil, ErrInviteNotFound
+	case n == 1:
+		return i, nil
+	case i.Expires != nil && !i.Expires.After(time.Now()):
+		logger.Warn().
+			Msg("Invite Code has expired.")
+		return nil, ErrInviteExpired
+	default:
+		logger.Warn().
+			Msg("Invite Code was already burned.")
+		return nil, ErrInviteBurned
 	}
+}
 
-	i.Burned = false
-	err = rs.Update(i)
+// UnburnInvite gives back a use taken by BurnInvite when the registration it
+// was taken for failed.
+func UnburnInvite(code string) error {
+	_, err := db.Update("invites").
+		Set("uses = uses - 1").
+		Set("burned = (uses >= max_uses)").
+		Where("code = ? AND uses > 0", code).
+		Exec()
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -71,3 +232,29 @@ func UnburnInvite(code string) error {
 	}
 	return err
 }
+
+// Redeem records that the profile registered with the invite.
+func (i *Invite) Redeem(profile uint64) error {
+	_, err := db.InsertInto("invite_redemptions").
+		Values(Redemption{InviteID: i.ID, ProfileID: profile}).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invite redemption could not be inserted into the table.")
+	}
+	return err
+}
+
+// forgetInviteUsage drops the links between a profile and the invites it
+// issued or redeemed, the codes and their use counts stay.
+func forgetInviteUsage(tx sqlbuilder.Tx, profile uint64) error {
+	if _, err := tx.DeleteFrom("invite_redemptions").Where("profile_id = ?", profile).Exec(); err != nil {
+		return err
+	}
+	_, err := tx.Update("invites").
+		Set("issuer_id = NULL").
+		Where("issuer_id = ?", profile).
+		Exec()
+	return err
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Judgements counts how many notes of a play were hit with each judgement,
+// stored as a JSON object in a single column.
+type Judgements map[string]uint64
+
+func (j Judgements) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(map[string]uint64(j))
+	return string(data), err
+}
+
+func (j *Judgements) Scan(src interface{}) error {
+	data, err := columnBytes(src)
+	if err != nil || len(data) == 0 {
+		*j = nil
+		return err
+	}
+	return json.Unmarshal(data, (*map[string]uint64)(j))
+}
+
+// Mods are the modifiers a play was made with, stored as a JSON array in a
+// single column.
+type Mods []string
+
+func (m Mods) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal([]string(m))
+	return string(data), err
+}
+
+func (m *Mods) Scan(src interface{}) error {
+	data, err := columnBytes(src)
+	if err != nil || len(data) == 0 {
+		*m = nil
+		return err
+	}
+	return json.Unmarshal(data, (*[]string)(m))
+}
+
+func columnBytes(src interface{}) ([]byte, error) {
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("Column cannot be scanned from %T.", src)
+	}
+}
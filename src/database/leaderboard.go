@@ -0,0 +1,364 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/logger"
+)
+
+// Standing is a profile's row on a leaderboard.
+type Standing struct {
+	Rank              uint64 `json:"rank"`
+	ID                uint64 `db:"id" json:"id"`
+	Handle            string `db:"username" json:"username"`
+	DisplayName       string `db:"display_name" json:"display_name,omitempty"`
+	Country           string `db:"country" json:"country,omitempty"`
+	Level             uint64 `db:"level" json:"level"`
+	Experience        uint64 `db:"experience" json:"experience"`
+	TotalScore        uint64 `db:"total_score" json:"total_score"`
+	PerformanceRating uint64 `db:"performance_rating" json:"performance_rating"`
+	PlayCount         uint64 `db:"play_count" json:"play_count"`
+	GlobalRank        uint64 `db:"global_rank" json:"global_rank,omitempty"`
+}
+
+// Cursor is the position after the last row of a leaderboard page.
+type Cursor struct {
+	Key []uint64
+	ID  uint64
+}
+
+var (
+	ErrUnknownSort   = errors.New("Leaderboard cannot be sorted that way.")
+	ErrInvalidCursor = errors.New("Leaderboard cursor is invalid.")
+	ErrNotRanked     = errors.New("Profile is not on the leaderboard.")
+)
+
+// sorts are the columns each leaderboard is ordered by, best first. Ties are
+// broken by id so every profile has a fixed place.
+var sorts = map[string][]string{
+	"performance_rating": {"performance_rating"},
+	"total_score":        {"total_score"},
+	"level":              {"level", "experience"},
+}
+
+var standingColumns = []interface{}{
+	"id", "username", "display_name", "country", "level", "experience",
+	"total_score", "performance_rating", "play_count",
+}
+
+// Board is a leaderboard over every profile that has played, or only a
+// part of them once it is scoped.
+type Board struct {
+	cols   []string
+	where  string
+	args   []interface{}
+	scoped bool
+	viewer uint64
+}
+
+func NewBoard(sort string) (*Board, error) {
+	cols, ok := sorts[sort]
+	if !ok {
+		return nil, ErrUnknownSort
+	}
+	return &Board{cols: cols, where: "play_count > 0"}, nil
+}
+
+// InCountry scopes the board to profiles from country.
+func (b *Board) InCountry(country string) *Board {
+	b.where += " AND country = ?"
+	b.args = append(b.args, country)
+	b.scoped = true
+	return b
+}
+
+// FollowedBy scopes the board to profile and the profiles it follows.
+func (b *Board) FollowedBy(profile uint64) *Board {
+	b.where += " AND (id = ? OR id IN (SELECT followee_id FROM follows WHERE follower_id = ?))"
+	b.args = append(b.args, profile, profile)
+	b.scoped = true
+	return b
+}
+
+// HiddenFrom leaves the profiles that blocked viewer off the rows returned,
+// they still count towards ranks.
+func (b *Board) HiddenFrom(viewer uint64) *Board {
+	b.viewer = viewer
+	return b
+}
+
+// columns selects a Standing, scoped boards also rank each row among every
+// profile that has played.
+func (b *Board) columns() []interface{} {
+	if !b.scoped {
+		return standingColumns
+	}
+
+	ors := make([]string, len(b.cols))
+	for i := range b.cols {
+		ands := []string{}
+		for j := 0; j < i; j++ {
+			ands = append(ands, "g."+b.cols[j]+" = profiles."+b.cols[j])
+		}
+		ands = append(ands, "g."+b.cols[i]+" > profiles."+b.cols[i])
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	global := "(SELECT COUNT(*) FROM profiles AS g WHERE g.play_count > 0 AND (" +
+		strings.Join(ors, " OR ") + ")) + 1 AS global_rank"
+	return append(append([]interface{}{}, standingColumns...), upper.Raw(global))
+}
+
+func (b *Board) key(s *Standing) []uint64 {
+	key := make([]uint64, len(b.cols))
+	for i, c := range b.cols {
+		switch c {
+		case "performance_rating":
+			key[i] = s.PerformanceRating
+		case "total_score":
+			key[i] = s.TotalScore
+		case "level":
+			key[i] = s.Level
+		case "experience":
+			key[i] = s.Experience
+		}
+	}
+	return key
+}
+
+// compare builds the condition for rows whose key is ordered op key,
+// comparing column by column.
+func (b *Board) compare(op string, key []uint64) (string, []interface{}) {
+	ors := make([]string, len(b.cols))
+	args := []interface{}{}
+	for i := range b.cols {
+		ands := []string{}
+		for j := 0; j < i; j++ {
+			ands = append(ands, b.cols[j]+" = ?")
+			args = append(args, key[j])
+		}
+		ands = append(ands, b.cols[i]+" "+op+" ?")
+		args = append(args, key[i])
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return strings.Join(ors, " OR "), args
+}
+
+// ties builds the condition for rows whose key equals key.
+func (b *Board) ties(key []uint64) (string, []interface{}) {
+	ands := make([]string, len(b.cols))
+	args := make([]interface{}, len(b.cols))
+	for i, c := range b.cols {
+		ands[i] = c + " = ?"
+		args[i] = key[i]
+	}
+	return strings.Join(ands, " AND "), args
+}
+
+// filter is the condition for rows on the board that also match cond,
+// followed by its arguments.
+func (b *Board) filter(cond string, args ...interface{}) []interface{} {
+	f := make([]interface{}, 0, 1+len(b.args)+len(args))
+	f = append(f, b.where+" AND ("+cond+")")
+	f = append(f, b.args...)
+	return append(f, args...)
+}
+
+// count counts the rows on the board matching cond.
+func (b *Board) count(cond string, args []interface{}) (uint64, error) {
+	n, err := db.Collection("profiles").
+		Find(b.filter(cond, args...)...).
+		Count()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Leaderboard could not be counted.")
+	}
+	return n, err
+}
+
+// rank is one more than the number of rows with a better key, rows with
+// the same key share a rank.
+func (b *Board) rank(s *Standing) (uint64, error) {
+	cond, args := b.compare(">", b.key(s))
+	n, err := b.count(cond, args)
+	return n + 1, err
+}
+
+// Page returns up to limit rows following after, or the top of the board if
+// after is nil.
+func (b *Board) Page(after *Cursor, limit uint) ([]Standing, error) {
+	cond, args := "TRUE", []interface{}{}
+	if after != nil {
+		if len(after.Key) != len(b.cols) {
+			return nil, ErrInvalidCursor
+		}
+		worse, wargs := b.compare("<", after.Key)
+		tie, targs := b.ties(after.Key)
+		cond = worse + " OR (" + tie + " AND id > ?)"
+		args = append(append(wargs, targs...), after.ID)
+	}
+	if b.viewer != 0 {
+		cond = "(" + cond + ") AND id NOT IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)"
+		args = append(args, b.viewer)
+	}
+
+	order := make([]interface{}, 0, len(b.cols)+1)
+	for _, c := range b.cols {
+		order = append(order, "-"+c)
+	}
+	order = append(order, "id")
+
+	ss := *new([]Standing)
+	err := db.Select(b.columns()...).
+		From("profiles").
+		Where(b.filter(cond, args...)...).
+		OrderBy(order...).
+		Limit(int(limit)).
+		All(&ss)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Leaderboard could not be selected.")
+		return nil, err
+	}
+	if len(ss) == 0 {
+		return ss, nil
+	}
+
+	// the first row is ranked by counting, the rest follow from it
+	first, err := b.rank(&ss[0])
+	if err != nil {
+		return nil, err
+	}
+	tie, targs := b.ties(b.key(&ss[0]))
+	before, err := b.count(tie+" AND id < ?", append(targs, ss[0].ID))
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := b.hidden()
+	if err != nil {
+		return nil, err
+	}
+	ss[0].Rank = first
+	pos := first + before
+	for i := 1; i < len(ss); i++ {
+		if equalKeys(b.key(&ss[i]), b.key(&ss[i-1])) {
+			ss[i].Rank = ss[i-1].Rank
+			continue
+		}
+		// hidden rows in between still take up places, unless they tie
+		ss[i].Rank = pos + uint64(i)
+		for j := range hidden {
+			h := &hidden[j]
+			if b.precedes(&ss[0], h) && b.precedes(h, &ss[i]) && !equalKeys(b.key(h), b.key(&ss[i])) {
+				ss[i].Rank++
+			}
+		}
+	}
+	return ss, nil
+}
+
+// hidden returns the rows on the board left out for the viewer.
+func (b *Board) hidden() ([]Standing, error) {
+	ss := *new([]Standing)
+	if b.viewer == 0 {
+		return ss, nil
+	}
+	err := db.Select(standingColumns...).
+		From("profiles").
+		Where(b.filter("id IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)", b.viewer)...).
+		All(&ss)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Hidden leaderboard rows could not be selected.")
+		return nil, err
+	}
+	return ss, nil
+}
+
+// precedes reports whether s comes before t on the board.
+func (b *Board) precedes(s, t *Standing) bool {
+	sk, tk := b.key(s), b.key(t)
+	for i := range sk {
+		if sk[i] != tk[i] {
+			return sk[i] > tk[i]
+		}
+	}
+	return s.ID < t.ID
+}
+
+// Standing returns the row of a profile on the board.
+func (b *Board) Standing(profile uint64) (*Standing, error) {
+	ss := *new([]Standing)
+	err := db.Select(b.columns()...).
+		From("profiles").
+		Where(b.filter("id = ?", profile)...).
+		Limit(1).
+		All(&ss)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Leaderboard standing could not be selected.")
+		return nil, err
+	}
+	if len(ss) == 0 {
+		return nil, ErrNotRanked
+	}
+
+	s := &ss[0]
+	if s.Rank, err = b.rank(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Next is the cursor continuing after s.
+func (b *Board) Next(s *Standing) *Cursor {
+	return &Cursor{Key: b.key(s), ID: s.ID}
+}
+
+func equalKeys(a, b []uint64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cursor) String() string {
+	parts := make([]string, len(c.Key))
+	for i, k := range c.Key {
+		parts[i] = strconv.FormatUint(k, 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, ",") + ":" + strconv.FormatUint(c.ID, 10)))
+}
+
+func ParseCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	i := strings.LastIndexByte(string(data), ':')
+	if i < 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	c := new(Cursor)
+	if c.ID, err = strconv.ParseUint(string(data[i+1:]), 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	for _, p := range strings.Split(string(data[:i]), ",") {
+		k, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Key = append(c.Key, k)
+	}
+	return c, nil
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"github.com/orchestrafm/profiles/src/progression"
+	"github.com/spidernest-go/logger"
+)
+
+const recomputeBatch = 500
+
+// RecomputeLevels derives the level of every profile, overall and in every
+// mode, from its experience again and returns how many changed. Profiles
+// that gained experience in the meantime already got their level from the
+// same curve and are left alone.
+func RecomputeLevels(c *progression.Curve) (uint64, error) {
+	changed, err := recomputeProfileLevels(c)
+	if err != nil {
+		return changed, err
+	}
+	for _, mode := range Modes {
+		n, err := recomputeModeLevels(c, mode)
+		changed += n
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+func recomputeProfileLevels(c *progression.Curve) (uint64, error) {
+	var changed, last uint64
+	for {
+		ps := *new([]Profile)
+		err := db.SelectFrom("profiles").
+			Where("id > ?", last).
+			OrderBy("id").
+			Limit(recomputeBatch).
+			All(&ps)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Profiles could not be selected for level recomputation.")
+			return changed, err
+		}
+		if len(ps) == 0 {
+			return changed, nil
+		}
+
+		for _, p := range ps {
+			last = p.ID
+			l := c.Level(p.Experience)
+			if l == p.Level {
+				continue
+			}
+			r, err := db.Update("profiles").
+				Set("level", l).
+				Where("id = ? AND experience = ?", p.ID, p.Experience).
+				Exec()
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Msgf("Level of profile %d could not be updated.", p.ID)
+				return changed, err
+			}
+			if n, _ := r.RowsAffected(); n == 1 {
+				changed++
+			}
+		}
+	}
+}
+
+func recomputeModeLevels(c *progression.Curve, mode string) (uint64, error) {
+	var changed, last uint64
+	for {
+		ss := *new([]ModeStats)
+		err := db.SelectFrom("profile_mode_stats").
+			Where("mode = ? AND profile_id > ?", mode, last).
+			OrderBy("profile_id").
+			Limit(recomputeBatch).
+			All(&ss)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Mode statistics could not be selected for level recomputation.")
+			return changed, err
+		}
+		if len(ss) == 0 {
+			return changed, nil
+		}
+
+		for _, s := range ss {
+			last = s.ProfileID
+			l := c.Level(s.Experience)
+			if l == s.Level {
+				continue
+			}
+			r, err := db.Update("profile_mode_stats").
+				Set("level", l).
+				Where("profile_id = ? AND mode = ? AND experience = ?", s.ProfileID, mode, s.Experience).
+				Exec()
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Msgf("Level of profile %d in %s could not be updated.", s.ProfileID, mode)
+				return changed, err
+			}
+			if n, _ := r.RowsAffected(); n == 1 {
+				changed++
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// maxLinksColumn is the width of the column links are stored in.
+const maxLinksColumn = 2048
+
+// Links is a list of URLs stored as a JSON array in a single column.
+type Links []string
+
+// encode renders the links as they are stored. json.Marshal would escape
+// the & and = of every query string, growing them past the column width.
+func (l Links) encode() (string, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode([]string(l)); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+func (l Links) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return "", nil
+	}
+	return l.encode()
+}
+
+func (l *Links) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("Links cannot be scanned from %T.", src)
+	}
+
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
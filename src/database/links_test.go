@@ -0,0 +1,30 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	l := Links{"https://example.com/?a=1&b=<2>", "https://example.org"}
+	v, err := l.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `["https://example.com/?a=1&b=<2>","https://example.org"]`
+	if v != want {
+		t.Errorf("got %s, want %s", v, want)
+	}
+
+	var back Links
+	if err := back.Scan([]byte(v.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, l) {
+		t.Errorf("got %v back, want %v", back, l)
+	}
+
+	if v, _ := Links(nil).Value(); v != "" {
+		t.Errorf("got %q for no links", v)
+	}
+}
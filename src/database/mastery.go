@@ -0,0 +1,180 @@
+package database
+
+import (
+	"math"
+
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+// Tier is a range of board difficulties, Max is exclusive and 0 for the
+// last tier.
+type Tier struct {
+	Name string  `json:"tier"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max,omitempty"`
+}
+
+// Tiers split boards by difficulty for mastery, later tiers weigh more.
+var Tiers = []Tier{
+	{"beginner", 0, 2},
+	{"easy", 2, 4},
+	{"normal", 4, 6},
+	{"hard", 6, 8},
+	{"expert", 8, 10},
+	{"master", 10, 0},
+}
+
+// Credit for a board towards mastery, by its best result.
+const (
+	creditCleared   = 0.5
+	creditFullCombo = 0.9
+	creditPerfect   = 1.0
+)
+
+// TierStats counts the boards of a tier a profile played, and on how many
+// of them it at least once cleared, full combo'd or played perfectly.
+type TierStats struct {
+	Tier
+	Played    uint64 `json:"played"`
+	Cleared   uint64 `json:"cleared"`
+	FullCombo uint64 `json:"full_combo"`
+	Perfect   uint64 `json:"perfect"`
+}
+
+// boardResult is the best a profile did on a board.
+type boardResult struct {
+	BoardID    uint64  `db:"board_id"`
+	Difficulty float64 `db:"difficulty"`
+	Cleared    bool    `db:"cleared"`
+	FullCombo  bool    `db:"full_combo"`
+	Perfect    bool    `db:"perfect"`
+}
+
+var boardResultColumns = []interface{}{
+	"board_id",
+	upper.Raw("MAX(difficulty) AS difficulty"),
+	upper.Raw("MAX(cleared) AS cleared"),
+	upper.Raw("MAX(full_combo) AS full_combo"),
+	upper.Raw("MAX(full_combo AND accuracy >= 100) AS perfect"),
+}
+
+// boardResults returns the best result on every board the profile played
+// in mode, or in every mode if mode is "".
+func boardResults(sb sqlbuilder.SQLBuilder, profile uint64, mode string) ([]boardResult, error) {
+	q := sb.Select(boardResultColumns...).
+		From("plays").
+		Where("profile_id = ?", profile)
+	if mode != "" {
+		q = q.And("mode = ?", mode)
+	}
+
+	rs := *new([]boardResult)
+	err := q.GroupBy("board_id").All(&rs)
+	return rs, err
+}
+
+// boardBest returns the best result on a board in mode, or in every mode if
+// mode is "", or nil if it was never played.
+func boardBest(sb sqlbuilder.SQLBuilder, profile, board uint64, mode string) (*boardResult, error) {
+	q := sb.Select(boardResultColumns...).
+		From("plays").
+		Where("profile_id = ? AND board_id = ?", profile, board)
+	if mode != "" {
+		q = q.And("mode = ?", mode)
+	}
+
+	rs := *new([]boardResult)
+	err := q.GroupBy("board_id").All(&rs)
+	if err != nil || len(rs) == 0 {
+		return nil, err
+	}
+	return &rs[0], nil
+}
+
+// improves reports whether the play does better on its board than best in
+// a way that counts towards mastery.
+func (pl *Play) improves(best *boardResult) bool {
+	return best == nil ||
+		(pl.Cleared && !best.Cleared) ||
+		(pl.FullCombo && !best.FullCombo) ||
+		(pl.perfect() && !best.Perfect)
+}
+
+func (pl *Play) perfect() bool {
+	return pl.FullCombo && pl.Accuracy >= 100
+}
+
+func tierOf(difficulty float64) int {
+	for i := len(Tiers) - 1; i > 0; i-- {
+		if difficulty >= Tiers[i].Min {
+			return i
+		}
+	}
+	return 0
+}
+
+// Mastery breaks the results down by tier and rates them from 0 to 100.
+// Every played board earns credit by its best result, weighted by its tier.
+func Mastery(rs []boardResult) (uint8, []TierStats) {
+	stats := make([]TierStats, len(Tiers))
+	for i := range Tiers {
+		stats[i].Tier = Tiers[i]
+	}
+
+	var earned, possible float64
+	for _, r := range rs {
+		t := tierOf(r.Difficulty)
+		w := float64(t + 1)
+		stats[t].Played++
+		possible += w
+
+		switch {
+		case r.Perfect:
+			earned += w * creditPerfect
+		case r.FullCombo:
+			earned += w * creditFullCombo
+		case r.Cleared:
+			earned += w * creditCleared
+		}
+		if r.Cleared {
+			stats[t].Cleared++
+		}
+		if r.FullCombo {
+			stats[t].FullCombo++
+		}
+		if r.Perfect {
+			stats[t].Perfect++
+		}
+	}
+
+	if possible == 0 {
+		return 0, stats
+	}
+	return uint8(math.Round(100 * earned / possible)), stats
+}
+
+// master sets the mastery of the profile from its plays.
+func (p *Profile) master(tx sqlbuilder.Tx) error {
+	rs, err := boardResults(tx, p.ID, "")
+	if err != nil {
+		return err
+	}
+	p.Mastery, _ = Mastery(rs)
+	return nil
+}
+
+// SelectMastery returns the mastery of a profile in mode, or in every mode
+// if mode is "", broken down by tier.
+func SelectMastery(profile uint64, mode string) (uint8, []TierStats, error) {
+	rs, err := boardResults(db, profile, mode)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Mastery of profile %d could not be selected.", profile)
+		return 0, nil, err
+	}
+	m, stats := Mastery(rs)
+	return m, stats, nil
+}
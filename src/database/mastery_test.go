@@ -0,0 +1,109 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestTierOf(t *testing.T) {
+	for _, tc := range []struct {
+		difficulty float64
+		want       string
+	}{
+		{0, "beginner"},
+		{1.99, "beginner"},
+		{2, "easy"},
+		{3.99, "easy"},
+		{4, "normal"},
+		{5.99, "normal"},
+		{6, "hard"},
+		{7.99, "hard"},
+		{8, "expert"},
+		{9.99, "expert"},
+		{10, "master"},
+		{50, "master"},
+	} {
+		if got := Tiers[tierOf(tc.difficulty)].Name; got != tc.want {
+			t.Errorf("difficulty %v: got %s, want %s", tc.difficulty, got, tc.want)
+		}
+	}
+}
+
+func TestMastery(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rs   []boardResult
+		want uint8
+	}{
+		{"nothing played", nil, 0},
+		{"played without clearing", []boardResult{{Difficulty: 1}}, 0},
+		{"cleared", []boardResult{{Difficulty: 1, Cleared: true}}, 50},
+		{"full combo", []boardResult{{Difficulty: 1, Cleared: true, FullCombo: true}}, 90},
+		{"perfect", []boardResult{{Difficulty: 1, Cleared: true, FullCombo: true, Perfect: true}}, 100},
+		// a master board weighs 6, a beginner board 1
+		{"perfect master board, unplayed beginner board", []boardResult{
+			{Difficulty: 10, Cleared: true, FullCombo: true, Perfect: true},
+			{Difficulty: 1},
+		}, 86},
+		{"perfect beginner board, unplayed master board", []boardResult{
+			{Difficulty: 1, Cleared: true, FullCombo: true, Perfect: true},
+			{Difficulty: 10},
+		}, 14},
+		{"cleared at a tier boundary", []boardResult{
+			{Difficulty: 2, Cleared: true},
+			{Difficulty: 1.99},
+		}, 33},
+	} {
+		got, _ := Mastery(tc.rs)
+		if got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMasteryStats(t *testing.T) {
+	_, stats := Mastery([]boardResult{
+		{Difficulty: 1.5, Cleared: true},
+		{Difficulty: 2, Cleared: true, FullCombo: true},
+		{Difficulty: 3, Cleared: true, FullCombo: true, Perfect: true},
+		{Difficulty: 12},
+	})
+	if len(stats) != len(Tiers) {
+		t.Fatalf("got %d tiers, want %d", len(stats), len(Tiers))
+	}
+	for _, tc := range []struct {
+		tier                                int
+		played, cleared, fullCombo, perfect uint64
+	}{
+		{0, 1, 1, 0, 0},
+		{1, 2, 2, 2, 1},
+		{2, 0, 0, 0, 0},
+		{5, 1, 0, 0, 0},
+	} {
+		s := stats[tc.tier]
+		if s.Played != tc.played || s.Cleared != tc.cleared || s.FullCombo != tc.fullCombo || s.Perfect != tc.perfect {
+			t.Errorf("%s: got %+v", s.Name, s)
+		}
+	}
+}
+
+func TestImproves(t *testing.T) {
+	cleared := &boardResult{Cleared: true}
+	for _, tc := range []struct {
+		name string
+		pl   Play
+		best *boardResult
+		want bool
+	}{
+		{"first play", Play{}, nil, true},
+		{"no better", Play{Cleared: true}, cleared, false},
+		{"first full combo", Play{Cleared: true, FullCombo: true}, cleared, true},
+		{"first perfect", Play{Cleared: true, FullCombo: true, Accuracy: 100},
+			&boardResult{Cleared: true, FullCombo: true}, true},
+		{"full combo short of perfect", Play{Cleared: true, FullCombo: true, Accuracy: 99.9},
+			&boardResult{Cleared: true, FullCombo: true}, false},
+	} {
+		if got := tc.pl.improves(tc.best); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
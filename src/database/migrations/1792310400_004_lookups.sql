@@ -0,0 +1,4 @@
+ALTER TABLE `profiles`
+    ADD `username` VARCHAR(255) NOT NULL DEFAULT '',
+    ADD INDEX `profiles_username` (`username`),
+    ADD INDEX `profiles_uuid` (`uuid`)
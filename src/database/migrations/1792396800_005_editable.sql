@@ -0,0 +1,7 @@
+ALTER TABLE `profiles`
+    ADD `display_name` VARCHAR(32) NOT NULL DEFAULT '',
+    ADD `bio` VARCHAR(1024) NOT NULL DEFAULT '',
+    ADD `country` CHAR(2) NOT NULL DEFAULT '',
+    ADD `links` VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD `pronouns` VARCHAR(32) NOT NULL DEFAULT '',
+    ADD `version` INT(8) UNSIGNED NOT NULL DEFAULT '1'
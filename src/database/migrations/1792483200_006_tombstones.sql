@@ -0,0 +1,8 @@
+CREATE TABLE `tombstones` (
+    `id` INT(8) UNSIGNED NOT NULL,
+    `uuid` VARCHAR(255) NOT NULL,
+    `requested` DATETIME NOT NULL DEFAULT NOW(),
+    `data_removed` BOOLEAN NOT NULL DEFAULT FALSE,
+    `identity_removed` BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (`id`)
+)
@@ -0,0 +1,10 @@
+CREATE TABLE `exports` (
+    `id` INT(8) UNSIGNED NOT NULL UNIQUE AUTO_INCREMENT,
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `token_hash` CHAR(64) NOT NULL UNIQUE,
+    `status` VARCHAR(16) NOT NULL DEFAULT 'pending',
+    `created` DATETIME NOT NULL DEFAULT NOW(),
+    `expires` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `exports_profile` (`profile_id`)
+)
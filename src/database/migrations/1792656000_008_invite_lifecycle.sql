@@ -0,0 +1,7 @@
+ALTER TABLE `invites`
+    ADD `expires` DATETIME NULL DEFAULT NULL,
+    ADD `max_uses` INT(8) UNSIGNED NOT NULL DEFAULT '1',
+    ADD `uses` INT(8) UNSIGNED NOT NULL DEFAULT '0',
+    ADD `issuer_id` INT(8) UNSIGNED NULL DEFAULT NULL,
+    ADD `created` DATETIME NOT NULL DEFAULT NOW(),
+    ADD INDEX `invites_issuer` (`issuer_id`)
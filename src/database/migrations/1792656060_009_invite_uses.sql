@@ -0,0 +1 @@
+UPDATE `invites` SET `uses` = 1 WHERE `burned` = TRUE
@@ -0,0 +1,7 @@
+CREATE TABLE `invite_redemptions` (
+    `invite_id` INT(8) UNSIGNED NOT NULL,
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `redeemed` DATETIME NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (`invite_id`, `profile_id`),
+    INDEX `invite_redemptions_profile` (`profile_id`)
+)
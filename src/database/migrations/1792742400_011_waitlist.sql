@@ -0,0 +1,5 @@
+ALTER TABLE `reqlist`
+    ADD `joined` DATETIME NOT NULL DEFAULT NOW(),
+    ADD `invited` DATETIME NULL DEFAULT NULL,
+    ADD `invite_id` INT(8) UNSIGNED NULL DEFAULT NULL,
+    ADD INDEX `reqlist_waitlist` (`invited`, `joined`)
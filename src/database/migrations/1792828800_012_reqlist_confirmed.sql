@@ -0,0 +1,2 @@
+ALTER TABLE `reqlist`
+    ADD `confirmed` DATETIME NULL DEFAULT NULL
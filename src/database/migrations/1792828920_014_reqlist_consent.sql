@@ -0,0 +1,9 @@
+CREATE TABLE `reqlist_consent` (
+    `id` INT(8) UNSIGNED NOT NULL AUTO_INCREMENT,
+    `email_hash` CHAR(64) NOT NULL,
+    `event` VARCHAR(16) NOT NULL,
+    `at` DATETIME NOT NULL DEFAULT NOW(),
+    `ip` VARCHAR(45) NOT NULL DEFAULT '',
+    PRIMARY KEY (`id`),
+    INDEX `reqlist_consent_email` (`email_hash`)
+)
@@ -0,0 +1,5 @@
+CREATE TABLE `settings` (
+    `name` VARCHAR(64) NOT NULL,
+    `value` VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (`name`)
+)
@@ -0,0 +1,12 @@
+CREATE TABLE `plays` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `board_id` INT(8) UNSIGNED NOT NULL,
+    `score` BIGINT UNSIGNED NOT NULL,
+    `accuracy` DOUBLE NOT NULL,
+    `difficulty` DOUBLE NOT NULL,
+    `performance` DOUBLE NOT NULL DEFAULT '0',
+    `played` DATETIME NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (`id`),
+    INDEX `plays_profile_board` (`profile_id`, `board_id`, `performance`)
+)
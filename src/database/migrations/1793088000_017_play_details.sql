@@ -0,0 +1,5 @@
+ALTER TABLE `plays`
+    ADD `judgements` VARCHAR(512) NOT NULL DEFAULT '',
+    ADD `mods` VARCHAR(512) NOT NULL DEFAULT '',
+    ADD INDEX `plays_recent` (`profile_id`, `played`),
+    ADD INDEX `plays_best` (`profile_id`, `board_id`, `score`)
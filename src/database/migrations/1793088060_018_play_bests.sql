@@ -0,0 +1,8 @@
+CREATE VIEW `play_bests` AS
+    SELECT `p`.* FROM `plays` AS `p`
+    WHERE NOT EXISTS (
+        SELECT 1 FROM `plays` AS `q`
+        WHERE `q`.`profile_id` = `p`.`profile_id`
+            AND `q`.`board_id` = `p`.`board_id`
+            AND (`q`.`score` > `p`.`score` OR (`q`.`score` = `p`.`score` AND `q`.`id` < `p`.`id`))
+    )
@@ -0,0 +1,4 @@
+ALTER TABLE `profiles`
+    ADD INDEX `profiles_performance_rating` (`performance_rating`, `id`),
+    ADD INDEX `profiles_total_score` (`total_score`, `id`),
+    ADD INDEX `profiles_level` (`level`, `experience`, `id`)
@@ -0,0 +1,7 @@
+CREATE TABLE `follows` (
+    `follower_id` INT(8) UNSIGNED NOT NULL,
+    `followee_id` INT(8) UNSIGNED NOT NULL,
+    `created` DATETIME NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (`follower_id`, `followee_id`),
+    INDEX `follows_followee` (`followee_id`, `follower_id`)
+)
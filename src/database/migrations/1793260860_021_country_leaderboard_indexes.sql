@@ -0,0 +1,4 @@
+ALTER TABLE `profiles`
+    ADD INDEX `profiles_country_performance_rating` (`country`, `performance_rating`, `id`),
+    ADD INDEX `profiles_country_total_score` (`country`, `total_score`, `id`),
+    ADD INDEX `profiles_country_level` (`country`, `level`, `experience`, `id`)
@@ -0,0 +1,3 @@
+ALTER TABLE `plays`
+    ADD `cleared` BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD `full_combo` BOOLEAN NOT NULL DEFAULT FALSE
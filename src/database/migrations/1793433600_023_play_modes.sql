@@ -0,0 +1,3 @@
+ALTER TABLE `plays`
+    ADD `mode` VARCHAR(8) NOT NULL DEFAULT '4k',
+    ADD INDEX `plays_profile_mode` (`profile_id`, `mode`, `board_id`)
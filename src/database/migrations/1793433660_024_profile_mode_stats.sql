@@ -0,0 +1,11 @@
+CREATE TABLE `profile_mode_stats` (
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `mode` VARCHAR(8) NOT NULL,
+    `experience` BIGINT UNSIGNED NOT NULL DEFAULT '0',
+    `level` INT(8) UNSIGNED NOT NULL DEFAULT '1',
+    `total_score` BIGINT UNSIGNED NOT NULL DEFAULT '0',
+    `play_count` INT(7) UNSIGNED NOT NULL DEFAULT '0',
+    `mastery` TINYINT(3) UNSIGNED NOT NULL DEFAULT '0',
+    `performance_rating` BIGINT UNSIGNED NOT NULL DEFAULT '0',
+    PRIMARY KEY (`profile_id`, `mode`)
+)
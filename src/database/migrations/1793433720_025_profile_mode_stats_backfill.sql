@@ -0,0 +1,4 @@
+INSERT INTO `profile_mode_stats`
+    (`profile_id`, `mode`, `experience`, `level`, `total_score`, `play_count`, `mastery`, `performance_rating`)
+    SELECT `id`, '4k', `experience`, `level`, `total_score`, `play_count`, `mastery`, `performance_rating`
+    FROM `profiles` WHERE `play_count` > 0
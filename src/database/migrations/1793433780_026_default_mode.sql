@@ -0,0 +1,2 @@
+ALTER TABLE `profiles`
+    ADD `default_mode` VARCHAR(8) NOT NULL DEFAULT '4k'
@@ -0,0 +1,10 @@
+CREATE TABLE `profile_history` (
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `day` DATE NOT NULL,
+    `performance_rating` BIGINT UNSIGNED NOT NULL,
+    `global_rank` INT(8) UNSIGNED NOT NULL,
+    `level` INT(8) UNSIGNED NOT NULL,
+    `play_count` INT(7) UNSIGNED NOT NULL,
+    PRIMARY KEY (`profile_id`, `day`),
+    INDEX `profile_history_day` (`day`)
+)
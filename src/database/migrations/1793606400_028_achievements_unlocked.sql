@@ -0,0 +1,6 @@
+CREATE TABLE `achievements_unlocked` (
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `achievement` VARCHAR(64) NOT NULL,
+    `unlocked` DATETIME NOT NULL,
+    PRIMARY KEY (`profile_id`, `achievement`)
+)
@@ -0,0 +1,7 @@
+CREATE TABLE `blocks` (
+    `blocker_id` INT(8) UNSIGNED NOT NULL,
+    `blocked_id` INT(8) UNSIGNED NOT NULL,
+    `created` DATETIME NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (`blocker_id`, `blocked_id`),
+    INDEX `blocks_blocked` (`blocked_id`, `blocker_id`)
+)
@@ -0,0 +1,3 @@
+ALTER TABLE `profiles`
+    ADD `avatar` VARCHAR(32) NOT NULL DEFAULT '',
+    ADD `banner` VARCHAR(32) NOT NULL DEFAULT ''
@@ -0,0 +1,10 @@
+CREATE TABLE `username_history` (
+    `id` INT(8) UNSIGNED NOT NULL AUTO_INCREMENT,
+    `profile_id` INT(8) UNSIGNED NOT NULL,
+    `username` VARCHAR(255) NOT NULL,
+    `forced` BOOLEAN NOT NULL DEFAULT FALSE,
+    `changed` DATETIME NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `username_history_profile` (`profile_id`, `changed`),
+    INDEX `username_history_username` (`username`, `changed`)
+)
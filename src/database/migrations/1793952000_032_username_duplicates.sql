@@ -0,0 +1,4 @@
+UPDATE `profiles` AS `p`
+    JOIN `profiles` AS `k` ON `k`.`username` = `p`.`username` AND `k`.`id` < `p`.`id`
+    SET `p`.`username` = ''
+    WHERE `p`.`username` != ''
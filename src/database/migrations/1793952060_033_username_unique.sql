@@ -0,0 +1,3 @@
+ALTER TABLE `profiles`
+    ADD `username_key` VARCHAR(255) AS (NULLIF(`username`, '')) STORED,
+    ADD UNIQUE INDEX `profiles_username_key` (`username_key`)
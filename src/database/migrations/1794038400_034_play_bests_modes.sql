@@ -0,0 +1,12 @@
+CREATE OR REPLACE VIEW `play_bests` AS
+    SELECT `p`.`id`, `p`.`profile_id`, `p`.`board_id`, `p`.`mode`, `p`.`score`, `p`.`accuracy`,
+        `p`.`difficulty`, `p`.`judgements`, `p`.`mods`, `p`.`cleared`, `p`.`full_combo`,
+        `p`.`performance`, `p`.`played`
+    FROM `plays` AS `p`
+    WHERE NOT EXISTS (
+        SELECT 1 FROM `plays` AS `q`
+        WHERE `q`.`profile_id` = `p`.`profile_id`
+            AND `q`.`board_id` = `p`.`board_id`
+            AND `q`.`mode` = `p`.`mode`
+            AND (`q`.`score` > `p`.`score` OR (`q`.`score` = `p`.`score` AND `q`.`id` < `p`.`id`))
+    )
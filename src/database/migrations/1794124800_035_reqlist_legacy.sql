@@ -0,0 +1,3 @@
+ALTER TABLE `reqlist`
+    ADD `legacy` BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD `reminded` DATETIME NULL DEFAULT NULL
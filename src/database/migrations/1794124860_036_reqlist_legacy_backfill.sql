@@ -0,0 +1 @@
+UPDATE `reqlist` SET `legacy` = TRUE WHERE `confirmed` IS NULL
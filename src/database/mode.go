@@ -0,0 +1,110 @@
+package database
+
+import (
+	"github.com/orchestrafm/profiles/src/progression"
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+// Modes are the key modes the game is played in.
+var Modes = []string{"4k", "5k", "7k"}
+
+// DefaultMode is the mode of plays reported without one, and the mode shown
+// to profiles that never picked one.
+const DefaultMode = "4k"
+
+func ValidMode(mode string) bool {
+	for _, m := range Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// ModeStats are the statistics of a profile in a single mode, the ones on
+// Profile itself cover every mode.
+type ModeStats struct {
+	ProfileID         uint64                `db:"profile_id" json:"-"`
+	Mode              string                `db:"mode" json:"mode"`
+	Experience        uint64                `db:"experience" json:"experience"`
+	Level             uint64                `db:"level" json:"level"`
+	Progress          *progression.Progress `db:"-" json:"progress,omitempty"`
+	TotalScore        uint64                `db:"total_score" json:"total_score"`
+	PlayCount         uint64                `db:"play_count" json:"play_count"`
+	Mastery           uint8                 `db:"mastery" json:"mastery"`
+	PerformanceRating uint64                `db:"performance_rating" json:"performance_rating"`
+}
+
+func (s *ModeStats) apply(pl *Play) {
+	s.PlayCount++
+	s.TotalScore += pl.Score
+	s.Experience += progression.Active.Award(pl.Score, pl.Accuracy, pl.Difficulty)
+	s.Level = progression.Active.Level(s.Experience)
+}
+
+func (s *ModeStats) rate(tx sqlbuilder.Tx) (err error) {
+	s.PerformanceRating, err = rating(tx, s.ProfileID, s.Mode, s.PlayCount)
+	return
+}
+
+func (s *ModeStats) master(tx sqlbuilder.Tx) error {
+	rs, err := boardResults(tx, s.ProfileID, s.Mode)
+	if err != nil {
+		return err
+	}
+	s.Mastery, _ = Mastery(rs)
+	return nil
+}
+
+// save writes the statistics, adding the row if the profile never played
+// the mode before.
+func (s *ModeStats) save(tx sqlbuilder.Tx) error {
+	_, err := tx.InsertInto("profile_mode_stats").
+		Values(s).
+		Amend(func(q string) string {
+			return q + " ON DUPLICATE KEY UPDATE" +
+				" `experience` = VALUES(`experience`), `level` = VALUES(`level`)," +
+				" `total_score` = VALUES(`total_score`), `play_count` = VALUES(`play_count`)," +
+				" `mastery` = VALUES(`mastery`), `performance_rating` = VALUES(`performance_rating`)"
+		}).
+		Exec()
+	return err
+}
+
+// selectModeStats returns the statistics of the profile in mode, profiles
+// that never played it get a fresh set.
+func selectModeStats(sb sqlbuilder.SQLBuilder, profile uint64, mode string, lock bool) (*ModeStats, error) {
+	q := sb.SelectFrom("profile_mode_stats").
+		Where("profile_id = ? AND mode = ?", profile, mode)
+	if lock {
+		q = q.Amend(forUpdate)
+	}
+
+	s := new(ModeStats)
+	err := q.One(s)
+	if err == upper.ErrNoMoreRows {
+		return &ModeStats{ProfileID: profile, Mode: mode, Level: progression.Active.Level(0)}, nil
+	}
+	return s, err
+}
+
+func SelectModeStats(profile uint64, mode string) (*ModeStats, error) {
+	s, err := selectModeStats(db, profile, mode, false)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Statistics of profile %d could not be selected.", profile)
+	}
+	return s, err
+}
+
+func SelectAllModeStats(profile uint64) ([]ModeStats, error) {
+	ss := *new([]ModeStats)
+	err := db.SelectFrom("profile_mode_stats").
+		Where("profile_id = ?", profile).
+		OrderBy("mode").
+		All(&ss)
+	return ss, err
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+
+	"github.com/orchestrafm/profiles/src/performance"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+// RecomputePerformance computes the performance of every stored play again
+// and then every profile's rating and mastery, it returns how many profiles
+// changed.
+func RecomputePerformance() (uint64, error) {
+	var last uint64
+	for {
+		pls := *new([]Play)
+		err := db.SelectFrom("plays").
+			Where("id > ?", last).
+			OrderBy("id").
+			Limit(recomputeBatch).
+			All(&pls)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Plays could not be selected for performance recomputation.")
+			return 0, err
+		}
+		if len(pls) == 0 {
+			break
+		}
+
+		for _, pl := range pls {
+			last = pl.ID
+			perf := performance.Play(pl.Score, pl.Accuracy, pl.Difficulty)
+			if perf == pl.Performance {
+				continue
+			}
+			_, err := db.Update("plays").
+				Set("performance", perf).
+				Where("id = ?", pl.ID).
+				Exec()
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Msgf("Performance of play %d could not be updated.", pl.ID)
+				return 0, err
+			}
+		}
+	}
+
+	var changed uint64
+	last = 0
+	for {
+		ps := *new([]Profile)
+		err := db.SelectFrom("profiles").
+			Where("id > ?", last).
+			OrderBy("id").
+			Limit(recomputeBatch).
+			All(&ps)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Profiles could not be selected for rating recomputation.")
+			return changed, err
+		}
+		if len(ps) == 0 {
+			return changed, nil
+		}
+
+		for _, p := range ps {
+			last = p.ID
+			ok, err := rerate(p.ID)
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Msgf("Rating of profile %d could not be updated.", p.ID)
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+	}
+}
+
+// rerate computes the rating and mastery of a profile again, it reports
+// whether either changed.
+func rerate(id uint64) (bool, error) {
+	changed := false
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		pf := new(Profile)
+		err := tx.SelectFrom("profiles").
+			Where("id = ?", id).
+			Amend(forUpdate).
+			One(pf)
+		if err != nil {
+			return err
+		}
+
+		rating, mastery := pf.PerformanceRating, pf.Mastery
+		if err := pf.rate(tx); err != nil {
+			return err
+		}
+		if err := pf.master(tx); err != nil {
+			return err
+		}
+		if pf.PerformanceRating != rating || pf.Mastery != mastery {
+			changed = true
+			_, err = tx.Update("profiles").
+				Set("performance_rating", pf.PerformanceRating).
+				Set("mastery", pf.Mastery).
+				Where("id = ?", id).
+				Exec()
+			if err != nil {
+				return err
+			}
+		}
+
+		ss := *new([]ModeStats)
+		if err := tx.SelectFrom("profile_mode_stats").Where("profile_id = ?", id).All(&ss); err != nil {
+			return err
+		}
+		for i := range ss {
+			s := &ss[i]
+			rating, mastery := s.PerformanceRating, s.Mastery
+			if err := s.rate(tx); err != nil {
+				return err
+			}
+			if err := s.master(tx); err != nil {
+				return err
+			}
+			if s.PerformanceRating == rating && s.Mastery == mastery {
+				continue
+			}
+			changed = true
+			if err := s.save(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return changed, err
+}
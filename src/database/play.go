@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/performance"
+	"github.com/orchestrafm/profiles/src/progression"
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+// Play is the result of a profile playing a board, as reported by the game
+// server.
+type Play struct {
+	ID          uint64     `db:"id,omitempty" json:"id"`
+	ProfileID   uint64     `db:"profile_id" json:"-"`
+	BoardID     uint64     `db:"board_id" json:"board_id"`
+	Mode        string     `db:"mode" json:"mode"`
+	Score       uint64     `db:"score" json:"score"`
+	Accuracy    float64    `db:"accuracy" json:"accuracy"`
+	Difficulty  float64    `db:"difficulty" json:"difficulty"`
+	Judgements  Judgements `db:"judgements" json:"judgements,omitempty"`
+	Mods        Mods       `db:"mods" json:"mods,omitempty"`
+	Cleared     bool       `db:"cleared" json:"cleared"`
+	FullCombo   bool       `db:"full_combo" json:"full_combo"`
+	Performance float64    `db:"performance" json:"performance"`
+	Played      time.Time  `db:"played" json:"played"`
+}
+
+const (
+	maxJudgements = 8
+	maxMods       = 16
+	// maxDifficulty is far above any board, it keeps performance and
+	// experience finite.
+	maxDifficulty = 50
+)
+
+// playKey is what judgement and mod names look like.
+var playKey = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+var ErrProfileNotFound = errors.New("Profile does not exist.")
+
+// Validate returns a message for every field that is not acceptable, keyed
+// by the field's JSON name.
+func (pl *Play) Validate() map[string]string {
+	errs := make(map[string]string)
+	if pl.BoardID == 0 {
+		errs["board_id"] = "Board is required."
+	}
+	pl.Mode = strings.ToLower(strings.TrimSpace(pl.Mode))
+	if pl.Mode == "" {
+		pl.Mode = DefaultMode
+	}
+	if !ValidMode(pl.Mode) {
+		errs["mode"] = "Mode must be 4k, 5k or 7k."
+	}
+	if pl.Accuracy < 0 || pl.Accuracy > 100 {
+		errs["accuracy"] = "Accuracy must be between 0 and 100."
+	}
+	if pl.Score > performance.MaxScore {
+		errs["score"] = "Score must be at most 1000000."
+	}
+	if pl.Difficulty < 0 || pl.Difficulty > maxDifficulty {
+		errs["difficulty"] = "Difficulty must be between 0 and 50."
+	}
+	if len(errs) == 0 {
+		p := performance.Play(pl.Score, pl.Accuracy, pl.Difficulty)
+		if math.IsNaN(p) || math.IsInf(p, 0) {
+			errs["difficulty"] = "Difficulty does not give a valid performance."
+		}
+	}
+	if pl.FullCombo && !pl.Cleared {
+		errs["full_combo"] = "A full combo has to clear the board."
+	}
+	if len(pl.Judgements) > maxJudgements {
+		errs["judgements"] = "At most 8 judgements are allowed."
+	}
+	for k := range pl.Judgements {
+		if !playKey.MatchString(k) {
+			errs["judgements"] = "Judgements must be named with at most 16 letters, digits or underscores."
+			break
+		}
+	}
+	if len(pl.Mods) > maxMods {
+		errs["mods"] = "At most 16 mods are allowed."
+	}
+	for _, m := range pl.Mods {
+		if !playKey.MatchString(m) {
+			errs["mods"] = "Mods must be named with at most 16 letters, digits or underscores."
+			break
+		}
+	}
+	return errs
+}
+
+// apply adds the play to the profile's statistics.
+func (p *Profile) apply(pl *Play) {
+	p.PlayCount++
+	p.TotalScore += pl.Score
+	p.Experience += progression.Active.Award(pl.Score, pl.Accuracy, pl.Difficulty)
+	p.Level = progression.Active.Level(p.Experience)
+}
+
+// RecordPlay stores a play and adds it to the statistics of a profile. The
+// row is locked for the duration of the transaction so concurrent plays of
+// the same profile are applied one after another.
+func RecordPlay(id uint64, pl *Play) (*Profile, error) {
+	pl.ID = 0
+	pl.ProfileID = id
+	pl.Played = time.Now()
+	pl.Performance = performance.Play(pl.Score, pl.Accuracy, pl.Difficulty)
+
+	pf := new(Profile)
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		err := tx.SelectFrom("profiles").
+			Where("id = ?", id).
+			Amend(forUpdate).
+			One(pf)
+		if err == upper.ErrNoMoreRows {
+			return ErrProfileNotFound
+		} else if err != nil {
+			return err
+		}
+
+		// mastery only moves when the play beats the best one on its board,
+		// overall for the profile and within the play's mode
+		best, err := boardBest(tx, id, pl.BoardID, "")
+		if err != nil {
+			return err
+		}
+		modeBest, err := boardBest(tx, id, pl.BoardID, pl.Mode)
+		if err != nil {
+			return err
+		}
+		r, err := tx.InsertInto("plays").
+			Values(pl).
+			Exec()
+		if err != nil {
+			return err
+		}
+		if n, err := r.LastInsertId(); err == nil {
+			pl.ID = uint64(n)
+		}
+
+		ms, err := selectModeStats(tx, id, pl.Mode, true)
+		if err != nil {
+			return err
+		}
+
+		pf.apply(pl)
+		ms.apply(pl)
+		// the rating's play count bonus grows with every play
+		if err := pf.rate(tx); err != nil {
+			return err
+		}
+		if err := ms.rate(tx); err != nil {
+			return err
+		}
+		if pl.improves(best) {
+			if err := pf.master(tx); err != nil {
+				return err
+			}
+		}
+		if pl.improves(modeBest) {
+			if err := ms.master(tx); err != nil {
+				return err
+			}
+		}
+		if err := ms.save(tx); err != nil {
+			return err
+		}
+		pf.ModeStats = ms
+		_, err = tx.Update("profiles").
+			Set(map[string]interface{}{
+				"experience":         pf.Experience,
+				"level":              pf.Level,
+				"total_score":        pf.TotalScore,
+				"play_count":         pf.PlayCount,
+				"mastery":            pf.Mastery,
+				"performance_rating": pf.PerformanceRating,
+			}).
+			Where("id = ?", id).
+			Exec()
+		return err
+	})
+	if err != nil {
+		if err != ErrProfileNotFound {
+			logger.Error().
+				Err(err).
+				Msgf("Play of profile %d could not be recorded.", id)
+		}
+		return nil, err
+	}
+	return pf, nil
+}
+
+func SelectPlaysOf(profile uint64) ([]Play, error) {
+	pls := *new([]Play)
+	err := db.SelectFrom("plays").
+		Where("profile_id = ?", profile).
+		OrderBy("id").
+		All(&pls)
+	return pls, err
+}
+
+// SelectRecentPlays returns a page of the profile's plays, newest first,
+// along with the total number of plays.
+func SelectRecentPlays(profile uint64, page, perPage uint) ([]Play, uint64, error) {
+	return pagePlays(db.SelectFrom("plays").
+		Where("profile_id = ?", profile).
+		OrderBy("-played", "-id").
+		Paginate(perPage), page)
+}
+
+// SelectBestPlays returns a page of the profile's best play on every board,
+// ordered by performance, along with the number of boards played.
+func SelectBestPlays(profile uint64, page, perPage uint) ([]Play, uint64, error) {
+	return pagePlays(db.SelectFrom("play_bests").
+		Where("profile_id = ?", profile).
+		OrderBy("-performance", "id").
+		Paginate(perPage), page)
+}
+
+func pagePlays(p sqlbuilder.Paginator, page uint) ([]Play, uint64, error) {
+	total, err := p.TotalEntries()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Plays could not be counted.")
+		return nil, 0, err
+	}
+
+	pls := *new([]Play)
+	if err := p.Page(page).All(&pls); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Plays could not be listed.")
+		return nil, 0, err
+	}
+	return pls, total, nil
+}
+
+// rate sets the performance rating from the best play on each of the
+// profile's top boards.
+func (p *Profile) rate(tx sqlbuilder.Tx) (err error) {
+	p.PerformanceRating, err = rating(tx, p.ID, "", p.PlayCount)
+	return
+}
+
+// rating rates the profile's plays in mode, or in every mode if mode is "".
+func rating(tx sqlbuilder.Tx, profile uint64, mode string, playCount uint64) (uint64, error) {
+	tops := *new([]struct {
+		Performance float64 `db:"performance"`
+	})
+	q := tx.Select(upper.Raw("MAX(performance) AS performance")).
+		From("plays").
+		Where("profile_id = ?", profile)
+	if mode != "" {
+		q = q.And("mode = ?", mode)
+	}
+	err := q.GroupBy("board_id").
+		OrderBy(upper.Raw("performance DESC")).
+		Limit(performance.TopPlays).
+		All(&tops)
+	if err != nil {
+		return 0, err
+	}
+
+	ps := make([]float64, len(tops))
+	for i := range tops {
+		ps[i] = tops[i].Performance
+	}
+	return uint64(performance.Rating(ps, playCount) + 0.5), nil
+}
+
+func forUpdate(q string) string {
+	return q + " FOR UPDATE"
+}
@@ -0,0 +1,26 @@
+package database
+
+import "testing"
+
+func TestPlayValidateMode(t *testing.T) {
+	for _, tc := range []struct {
+		mode  string
+		want  string
+		valid bool
+	}{
+		{"", DefaultMode, true},
+		{"7k", "7k", true},
+		{"7K", "7k", true},
+		{" 4K ", "4k", true},
+		{"6k", "6k", false},
+	} {
+		pl := &Play{BoardID: 1, Mode: tc.mode, Accuracy: 90, Score: 500000, Difficulty: 10}
+		errs := pl.Validate()
+		if pl.Mode != tc.want {
+			t.Errorf("%q: got mode %q, want %q", tc.mode, pl.Mode, tc.want)
+		}
+		if _, bad := errs["mode"]; bad == tc.valid {
+			t.Errorf("%q: got errors %v", tc.mode, errs)
+		}
+	}
+}
@@ -1,16 +1,50 @@
 package database
 
+import (
+	"time"
+
+	"github.com/orchestrafm/profiles/src/progression"
+)
+
 type Profile struct {
-	ID                uint64   `db:"id" json:"id"`
-	UUID              string   `db:"uuid" json:"uuid,omitempty"`
-	Username          string   `json:"name,omitempty"`
-	Groups            []string `json:"groups,omitempty"`
-	Experience        uint64   `db:"experience" json:"experience"`
-	Level             uint64   `db:"level" json:"level"`
-	TotalScore        uint64   `db:"total_score" json:"total_score"`
-	PlayCount         uint64   `db:"play_count" json:"play_count"`
-	Mastery           uint8    `db:"mastery" json:"mastery"`
-	PerformanceRating uint64   `db:"performance_rating" json:"performance_rating"`
+	ID                uint64                `db:"id" json:"id"`
+	UUID              string                `db:"uuid" json:"uuid,omitempty"`
+	Handle            string                `db:"username,omitempty" json:"username,omitempty"`
+	Username          string                `json:"name,omitempty"`
+	Groups            []string              `json:"groups,omitempty"`
+	Experience        uint64                `db:"experience" json:"experience"`
+	Level             uint64                `db:"level" json:"level"`
+	Progress          *progression.Progress `db:"-" json:"progress,omitempty"`
+	TotalScore        uint64                `db:"total_score" json:"total_score"`
+	PlayCount         uint64                `db:"play_count" json:"play_count"`
+	Mastery           uint8                 `db:"mastery" json:"mastery"`
+	PerformanceRating uint64                `db:"performance_rating" json:"performance_rating"`
+	DisplayName       string                `db:"display_name,omitempty" json:"display_name,omitempty"`
+	Bio               string                `db:"bio,omitempty" json:"bio,omitempty"`
+	Country           string                `db:"country,omitempty" json:"country,omitempty"`
+	Links             Links                 `db:"links,omitempty" json:"links,omitempty"`
+	Pronouns          string                `db:"pronouns,omitempty" json:"pronouns,omitempty"`
+	DefaultMode       string                `db:"default_mode,omitempty" json:"default_mode"`
+	ModeStats         *ModeStats            `db:"-" json:"mode_stats,omitempty"`
+	AvatarFile        string                `db:"avatar,omitempty" json:"-"`
+	Avatar            map[string]string     `db:"-" json:"avatar,omitempty"`
+	BannerFile        string                `db:"banner,omitempty" json:"-"`
+	Banner            map[string]string     `db:"-" json:"banner,omitempty"`
+	Followers         uint64                `db:"-" json:"followers"`
+	Following         uint64                `db:"-" json:"following"`
+	Version           uint64                `db:"version,omitempty" json:"version"`
+	DateCreated       time.Time             `db:"date_created,omitempty" json:"date_created"`
+}
+
+// ProfileEdit holds the owner editable fields of a profile, nil fields are
+// left untouched.
+type ProfileEdit struct {
+	DisplayName *string   `json:"display_name"`
+	Bio         *string   `json:"bio"`
+	Country     *string   `json:"country"`
+	Links       *[]string `json:"links"`
+	Pronouns    *string   `json:"pronouns"`
+	DefaultMode *string   `json:"default_mode"`
 }
 
 type Registration struct {
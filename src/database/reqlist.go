@@ -1,5 +1,217 @@
 package database
 
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+// ReqList is an entry on the mailing list, it joins the waitlist once the
+// email was confirmed and stays waitlisted until it was sent an invite.
 type ReqList struct {
-	Email string `db:"email" json:"email,omitempty"`
+	Email     string     `db:"email" json:"email,omitempty"`
+	Joined    time.Time  `db:"joined,omitempty" json:"joined"`
+	Confirmed *time.Time `db:"confirmed" json:"confirmed,omitempty"`
+	Invited   *time.Time `db:"invited" json:"invited,omitempty"`
+	InviteID  *uint64    `db:"invite_id" json:"invite_id,omitempty"`
+	// Legacy entries joined before confirmation was required, they are
+	// kept until Reminded, when they were asked to confirm, is long ago.
+	Legacy   bool       `db:"legacy" json:"legacy,omitempty"`
+	Reminded *time.Time `db:"reminded" json:"reminded,omitempty"`
+}
+
+var ErrNotWaitlisted = errors.New("Email is not on the waitlist.")
+
+func SelectReqList(email string) (error, *ReqList) {
+	r := *new(ReqList)
+	err := db.SelectFrom("reqlist").
+		Where("email = ?", email).
+		Limit(1).
+		One(&r)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &r
+}
+
+// WaitlistSize counts the entries that have not been invited yet.
+func WaitlistSize() (uint64, error) {
+	n, err := db.Collection("reqlist").
+		Find("confirmed IS NOT NULL AND invited IS NULL").
+		Count()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Waitlist could not be counted.")
+	}
+	return n, err
+}
+
+// WaitlistPosition is the 1-based place of email in the queue, entries that
+// joined at the same time are ordered by email.
+func WaitlistPosition(email string) (uint64, error) {
+	err, r := SelectReqList(email)
+	if err != nil || r.Confirmed == nil || r.Invited != nil {
+		return 0, ErrNotWaitlisted
+	}
+
+	n, err := db.Collection("reqlist").
+		Find("confirmed IS NOT NULL AND invited IS NULL AND (joined < ? OR (joined = ? AND email <= ?))", r.Joined, r.Joined, r.Email).
+		Count()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Waitlist position could not be counted.")
+	}
+	return n, err
+}
+
+// OldestWaitlisted returns up to n entries at the front of the queue.
+func OldestWaitlisted(n int) ([]ReqList, error) {
+	rs := *new([]ReqList)
+	err := db.SelectFrom("reqlist").
+		Where("confirmed IS NOT NULL AND invited IS NULL").
+		OrderBy("joined", "email").
+		Limit(n).
+		All(&rs)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Waitlist could not be selected from the table.")
+	}
+	return rs, err
+}
+
+// MarkInvited takes the entry off the waitlist, it reports false when it
+// was already invited by someone else.
+func (r *ReqList) MarkInvited(invite uint64) (bool, error) {
+	now := time.Now()
+	res, err := db.Update("reqlist").
+		Set("invited", now).
+		Set("invite_id", invite).
+		Where("email = ? AND invited IS NULL", r.Email).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Waitlist entry could not be updated.")
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	r.Invited, r.InviteID = &now, &invite
+	return true, nil
+}
+
+// Unmark puts the entry back on the waitlist at its old place.
+func (r *ReqList) Unmark() error {
+	_, err := db.Update("reqlist").
+		Set("invited = NULL").
+		Set("invite_id = NULL").
+		Where("email = ?", r.Email).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Waitlist entry could not be updated.")
+		return err
+	}
+	r.Invited, r.InviteID = nil, nil
+	return nil
+}
+
+// Confirm activates the entry, it reports false when it was already
+// confirmed.
+func (r *ReqList) Confirm() (bool, error) {
+	now := time.Now()
+	res, err := db.Update("reqlist").
+		Set("confirmed", now).
+		Where("email = ? AND confirmed IS NULL", r.Email).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Mailing list entry could not be confirmed.")
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	r.Confirmed = &now
+	return true, nil
+}
+
+// SelectUnremindedLegacy returns up to n legacy entries that haven't been
+// asked to confirm yet.
+func SelectUnremindedLegacy(n int) ([]ReqList, error) {
+	rs := *new([]ReqList)
+	err := db.SelectFrom("reqlist").
+		Where("legacy = TRUE AND confirmed IS NULL AND reminded IS NULL").
+		OrderBy("joined", "email").
+		Limit(n).
+		All(&rs)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Legacy entries could not be selected from the table.")
+	}
+	return rs, err
+}
+
+// MarkReminded records that the legacy entry was asked to confirm, from
+// then on it expires like any other unconfirmed entry.
+func (r *ReqList) MarkReminded() error {
+	now := time.Now()
+	_, err := db.Update("reqlist").
+		Set("reminded", now).
+		Where("email = ?", r.Email).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Legacy entry could not be updated.")
+		return err
+	}
+	r.Reminded = &now
+	return nil
+}
+
+// RemoveUnconfirmed deletes entries that joined before t and were never
+// confirmed, along with the record of them asking to join. Legacy entries
+// are only deleted once they were reminded before t.
+func RemoveUnconfirmed(t time.Time) error {
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		rs := *new([]ReqList)
+		err := tx.SelectFrom("reqlist").
+			Where("confirmed IS NULL AND ((legacy = FALSE AND joined < ?) OR (legacy = TRUE AND reminded < ?))", t, t).
+			Amend(forUpdate).
+			All(&rs)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			_, err := tx.DeleteFrom("reqlist_consent").
+				Where("email_hash = ? AND event = ?", hashEmail(r.Email), ConsentRequested).
+				Exec()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.DeleteFrom("reqlist").Where("email = ?", r.Email).Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Unconfirmed entries could not be deleted.")
+	}
+	return err
 }
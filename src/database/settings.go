@@ -0,0 +1,39 @@
+package database
+
+import (
+	"github.com/spidernest-go/logger"
+)
+
+// Setting is a value the service keeps across restarts.
+type Setting struct {
+	Name  string `db:"name"`
+	Value string `db:"value"`
+}
+
+// GetSetting returns the stored value of name, or "" if it was never set.
+func GetSetting(name string) (string, error) {
+	ss := *new([]Setting)
+	err := db.SelectFrom("settings").
+		Where("name = ?", name).
+		Limit(1).
+		All(&ss)
+	if err != nil || len(ss) == 0 {
+		return "", err
+	}
+	return ss[0].Value, nil
+}
+
+func PutSetting(name, value string) error {
+	_, err := db.InsertInto("settings").
+		Values(Setting{name, value}).
+		Amend(func(q string) string {
+			return q + " ON DUPLICATE KEY UPDATE `value` = VALUES(`value`)"
+		}).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Setting %s could not be stored.", name)
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+// Tombstone records a deleted profile's id so it is never handed out again,
+// and how far the deletion got so it can be resumed.
+type Tombstone struct {
+	ID              uint64    `db:"id" json:"id"`
+	UUID            string    `db:"uuid" json:"-"`
+	Requested       time.Time `db:"requested,omitempty" json:"requested"`
+	DataRemoved     bool      `db:"data_removed" json:"data_removed"`
+	IdentityRemoved bool      `db:"identity_removed" json:"identity_removed"`
+}
+
+// Done reports whether both the database and identity provider sides of the
+// deletion have completed.
+func (t *Tombstone) Done() bool {
+	return t.DataRemoved && t.IdentityRemoved
+}
+
+// Bury starts deleting the profile by recording its tombstone, an existing
+// tombstone for the same profile is returned as is. That includes one a
+// concurrent request inserted between the lookup and the insert.
+func (p *Profile) Bury() (*Tombstone, error) {
+	if err, t := SelectTombstone(p.ID); err == nil {
+		return t, nil
+	}
+
+	t := &Tombstone{ID: p.ID, UUID: p.UUID}
+	_, err := db.InsertInto("tombstones").
+		Values(t).
+		Exec()
+	if isDuplicate(err) {
+		if err, t := SelectTombstone(p.ID); err == nil {
+			return t, nil
+		}
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Tombstone could not be inserted into the table.")
+		return nil, err
+	}
+	return t, nil
+}
+
+func SelectTombstone(id uint64) (error, *Tombstone) {
+	t := *new(Tombstone)
+	err := db.SelectFrom("tombstones").
+		Where("id = ?", id).
+		Limit(1).
+		One(&t)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &t
+}
+
+// PendingTombstones lists deletions that have yet to complete.
+func PendingTombstones() ([]Tombstone, error) {
+	ts := *new([]Tombstone)
+	err := db.SelectFrom("tombstones").
+		Where("data_removed = FALSE OR identity_removed = FALSE").
+		OrderBy("requested").
+		All(&ts)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Pending tombstones could not be listed.")
+	}
+	return ts, err
+}
+
+// RemoveData deletes the profile and every row depending on it in a single
+// transaction, email is the account's address if it is still known.
+func (t *Tombstone) RemoveData(email string) error {
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		if _, err := tx.DeleteFrom("profiles").Where("id = ?", t.ID).Exec(); err != nil {
+			return err
+		}
+		if err := forgetInviteUsage(tx, t.ID); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("plays").Where("profile_id = ?", t.ID).Exec(); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("profile_mode_stats").Where("profile_id = ?", t.ID).Exec(); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("profile_history").Where("profile_id = ?", t.ID).Exec(); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("achievements_unlocked").Where("profile_id = ?", t.ID).Exec(); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("follows").Where("follower_id = ? OR followee_id = ?", t.ID, t.ID).Exec(); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("username_history").Where("profile_id = ?", t.ID).Exec(); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteFrom("blocks").Where("blocker_id = ? OR blocked_id = ?", t.ID, t.ID).Exec(); err != nil {
+			return err
+		}
+		if email != "" {
+			if _, err := tx.DeleteFrom("reqlist").Where("email = ?", email).Exec(); err != nil {
+				return err
+			}
+			if err := forgetConsent(tx, email); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Update("tombstones").
+			Set("data_removed", true).
+			Where("id = ?", t.ID).
+			Exec()
+		return err
+	})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Data of profile %d could not be removed.", t.ID)
+		return err
+	}
+
+	t.DataRemoved = true
+	return nil
+}
+
+// MarkIdentityRemoved records that the identity provider no longer has the
+// account, the uuid is dropped since nothing needs it anymore.
+func (t *Tombstone) MarkIdentityRemoved() error {
+	_, err := db.Update("tombstones").
+		Set("identity_removed", true).
+		Set("uuid", "").
+		Where("id = ?", t.ID).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Tombstone of profile %d could not be updated.", t.ID)
+		return err
+	}
+
+	t.IdentityRemoved = true
+	t.UUID = ""
+	return nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/spidernest-go/logger"
+)
+
+var ErrVersionMismatch = errors.New("Profile was changed by someone else.")
+
+// Edit applies e to the profile if it is still at version, the profile is
+// reloaded afterwards so it reflects the stored row.
+func (p *Profile) Edit(e *ProfileEdit, version uint64) error {
+	set := map[string]interface{}{}
+	if e.DisplayName != nil {
+		set["display_name"] = *e.DisplayName
+	}
+	if e.Bio != nil {
+		set["bio"] = *e.Bio
+	}
+	if e.Country != nil {
+		set["country"] = *e.Country
+	}
+	if e.Links != nil {
+		links, err := Links(*e.Links).Value()
+		if err != nil {
+			return err
+		}
+		set["links"] = links
+	}
+	if e.Pronouns != nil {
+		set["pronouns"] = *e.Pronouns
+	}
+	if e.DefaultMode != nil {
+		set["default_mode"] = *e.DefaultMode
+	}
+
+	r, err := db.Update("profiles").
+		Set(set).
+		Set("version = version + 1").
+		Where("id = ? AND version = ?", p.ID, version).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Profile could not be updated.")
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrVersionMismatch
+	}
+
+	err, pf := SelectProfileById(p.ID)
+	if err != nil {
+		return err
+	}
+	*p = *pf
+	return nil
+}
+
+// SetImage points the avatar or banner column at a newly stored image and
+// bumps the version like any other edit.
+func (p *Profile) SetImage(column, file string) error {
+	if column != "avatar" && column != "banner" {
+		return errors.New("Profile has no image column " + column + ".")
+	}
+
+	_, err := db.Update("profiles").
+		Set(column, file).
+		Set("version = version + 1").
+		Where("id = ?", p.ID).
+		Exec()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Image (%s) of profile %d could not be updated.", column, p.ID)
+		return err
+	}
+
+	if column == "avatar" {
+		p.AvatarFile = file
+	} else {
+		p.BannerFile = file
+	}
+	p.Version++
+	return nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	upper "github.com/spidernest-go/db"
+	"github.com/spidernest-go/db/lib/sqlbuilder"
+	"github.com/spidernest-go/logger"
+)
+
+const (
+	// RenameCooldown is how long owners wait between renames.
+	RenameCooldown = 30 * 24 * time.Hour
+
+	// NameReservation is how long a previous username can't be taken by
+	// anyone else. Names an admin forced away are never free again.
+	NameReservation = 90 * 24 * time.Hour
+)
+
+var ErrUsernameTaken = errors.New("Username is taken.")
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,31}$`)
+
+// PreviousName is a username a profile had until Changed.
+type PreviousName struct {
+	ID        uint64    `db:"id,omitempty" json:"-"`
+	ProfileID uint64    `db:"profile_id" json:"-"`
+	Username  string    `db:"username" json:"username"`
+	Forced    bool      `db:"forced" json:"forced,omitempty"`
+	Changed   time.Time `db:"changed" json:"changed"`
+}
+
+// ValidUsername reports whether name is 3 to 32 lowercase letters, digits,
+// dots, dashes or underscores, starting with a letter or digit.
+func ValidUsername(name string) bool {
+	return usernamePattern.MatchString(name)
+}
+
+// LastRename returns when the profile last changed its own username, or
+// the zero time if it never did. Forced renames don't count.
+func LastRename(profile uint64) (time.Time, error) {
+	rows := *new([]PreviousName)
+	err := db.SelectFrom("username_history").
+		Where("profile_id = ? AND forced = FALSE", profile).
+		OrderBy("-changed").
+		Limit(1).
+		All(&rows)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Renames of profile %d could not be selected.", profile)
+		return time.Time{}, err
+	}
+	if len(rows) == 0 {
+		return time.Time{}, nil
+	}
+	return rows[0].Changed, nil
+}
+
+// NameReserved reports whether name is some other profile's username, or
+// was recently enough for it to be held back.
+func NameReserved(name string, profile uint64) (bool, error) {
+	name = strings.ToLower(name)
+	n, err := db.Collection("profiles").
+		Find("username = ? AND id != ?", name, profile).
+		Count()
+	if err == nil && n == 0 {
+		n, err = db.Collection("username_history").
+			Find("username = ? AND profile_id != ? AND (forced = TRUE OR changed > ?)",
+				name, profile, time.Now().Add(-NameReservation)).
+			Count()
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Username (%s) could not be looked up.", name)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Rename records the profile's current username in its history and takes
+// on name, forced marks renames made by an admin.
+func (p *Profile) Rename(old, name string, forced bool) error {
+	name = strings.ToLower(name)
+	err := db.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		if old != "" {
+			_, err := tx.InsertInto("username_history").
+				Values(&PreviousName{
+					ProfileID: p.ID,
+					Username:  strings.ToLower(old),
+					Forced:    forced,
+					Changed:   time.Now(),
+				}).
+				Exec()
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.Update("profiles").
+			Set("username", name).
+			Set("version = version + 1").
+			Where("id = ?", p.ID).
+			Exec()
+		return err
+	})
+	if isDuplicate(err) {
+		// another profile took the name since it was checked
+		return ErrUsernameTaken
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profile %d could not be renamed.", p.ID)
+		return err
+	}
+
+	p.Handle = name
+	p.Version++
+	return nil
+}
+
+// SelectProfileByPreviousName returns the profile that most recently gave
+// up name, unless an admin took it away.
+func SelectProfileByPreviousName(name string) (error, *Profile) {
+	rows := *new([]PreviousName)
+	err := db.SelectFrom("username_history").
+		Where("username = ? AND forced = FALSE", strings.ToLower(name)).
+		OrderBy("-changed").
+		Limit(1).
+		All(&rows)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Previous username (%s) could not be looked up.", name)
+		return err, nil
+	}
+	if len(rows) == 0 {
+		return upper.ErrNoMoreRows, nil
+	}
+	return SelectProfileById(rows[0].ProfileID)
+}
+
+// SelectPreviousNames returns the usernames the profile had, newest first,
+// forced ones only if all is set.
+func SelectPreviousNames(profile uint64, all bool) ([]PreviousName, error) {
+	q := db.SelectFrom("username_history").
+		Where("profile_id = ?", profile)
+	if !all {
+		q = q.And("forced = FALSE")
+	}
+
+	ns := *new([]PreviousName)
+	if err := q.OrderBy("-changed").All(&ns); err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Previous usernames of profile %d could not be selected.", profile)
+		return nil, err
+	}
+	return ns, nil
+}
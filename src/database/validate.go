@@ -0,0 +1,123 @@
+package database
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxDisplayName = 32
+	maxBio         = 1024
+	maxPronouns    = 32
+	maxLinks       = 5
+	maxLinkLength  = 255
+)
+
+// countries are the ISO 3166-1 alpha-2 codes a profile may set.
+var countries = strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ
+	BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR
+	CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR
+	GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU
+	ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ
+	LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+	MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF
+	PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI
+	SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR
+	TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW`)
+
+// ValidCountry reports whether code is an ISO 3166-1 alpha-2 country code.
+func ValidCountry(code string) bool {
+	for _, c := range countries {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate normalizes the edit and returns a message for every field that
+// is not acceptable, keyed by the field's JSON name.
+func (e *ProfileEdit) Validate() map[string]string {
+	errs := make(map[string]string)
+
+	if e.DisplayName != nil {
+		*e.DisplayName = strings.TrimSpace(*e.DisplayName)
+		switch n := utf8.RuneCountInString(*e.DisplayName); {
+		case n > maxDisplayName:
+			errs["display_name"] = "Display name must be at most 32 characters."
+		case !printable(*e.DisplayName, false):
+			errs["display_name"] = "Display name must not contain control characters."
+		}
+	}
+
+	if e.Bio != nil {
+		*e.Bio = strings.TrimSpace(*e.Bio)
+		switch {
+		case utf8.RuneCountInString(*e.Bio) > maxBio:
+			errs["bio"] = "Bio must be at most 1024 characters."
+		case !printable(*e.Bio, true):
+			errs["bio"] = "Bio must not contain control characters."
+		}
+	}
+
+	if e.Country != nil {
+		*e.Country = strings.ToUpper(strings.TrimSpace(*e.Country))
+		if *e.Country != "" && !ValidCountry(*e.Country) {
+			errs["country"] = "Country must be an ISO 3166-1 alpha-2 code."
+		}
+	}
+
+	if e.Links != nil {
+		if len(*e.Links) > maxLinks {
+			errs["links"] = "At most 5 links are allowed."
+		}
+		for i, l := range *e.Links {
+			l = strings.TrimSpace(l)
+			(*e.Links)[i] = l
+			u, err := url.Parse(l)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(l) > maxLinkLength {
+				errs["links"] = "Links must be absolute http or https URLs of at most 255 characters."
+				break
+			}
+		}
+		if _, bad := errs["links"]; !bad {
+			if data, err := Links(*e.Links).encode(); err != nil || utf8.RuneCountInString(data) > maxLinksColumn {
+				errs["links"] = "Links must be at most 2048 characters together."
+			}
+		}
+	}
+
+	if e.Pronouns != nil {
+		*e.Pronouns = strings.TrimSpace(*e.Pronouns)
+		switch {
+		case utf8.RuneCountInString(*e.Pronouns) > maxPronouns:
+			errs["pronouns"] = "Pronouns must be at most 32 characters."
+		case !printable(*e.Pronouns, false):
+			errs["pronouns"] = "Pronouns must not contain control characters."
+		}
+	}
+
+	if e.DefaultMode != nil {
+		*e.DefaultMode = strings.ToLower(strings.TrimSpace(*e.DefaultMode))
+		if !ValidMode(*e.DefaultMode) {
+			errs["default_mode"] = "Default mode must be 4k, 5k or 7k."
+		}
+	}
+
+	return errs
+}
+
+func printable(s string, multiline bool) bool {
+	for _, r := range s {
+		if multiline && r == '\n' {
+			continue
+		}
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return false
+		}
+	}
+	return true
+}
@@ -19,6 +19,29 @@ var (
 	NonceEnabledVerifier *oidc.IDTokenVerifier
 	OAuth2               oauth2.Config
 	Nonce                string
+
+	// Scopes are the Orchestra resource scopes requested on login and
+	// enforced by the policy package.
+	Scopes = []string{
+		"track:read",
+		"track:write",
+		"track:admin",
+		"board:read",
+		"board:write",
+		"board:admin",
+		"score:read",
+		"score:write",
+		"score:admin",
+		"update:read",
+		"update:write",
+		"update:admin",
+		"profile:read",
+		"profile:write",
+		"profile:admin",
+		"invite:read",
+		"invite:write",
+		"invite:admin",
+	}
 )
 
 func EnableOIDC() {
@@ -51,22 +74,7 @@ func EnableOIDC() {
 		ClientSecret: clientSecret,
 		Endpoint:     provider.Endpoint(),
 		RedirectURL:  "http://localhost:5000/api/v0/oidc/callback",
-		Scopes: []string{oidc.ScopeOpenID,
-			"profile",
-			"email",
-			"track:read",
-			"track:write",
-			"track:admin",
-			"board:read",
-			"board:write",
-			"board:admin",
-			"score:read",
-			"score:write",
-			"score:admin",
-			"update:read",
-			"update:write",
-			"update:admin",
-		},
+		Scopes:       append([]string{oidc.ScopeOpenID, "profile", "email"}, Scopes...),
 	}
 
 	logger.Info().
@@ -2,6 +2,7 @@ package identity
 
 import (
 	srand "crypto/rand"
+	"math/big"
 	"math/rand"
 )
 
@@ -43,3 +44,23 @@ func GetRandomString(n int) string {
 
 	return string(b)
 }
+
+// InviteAlphabet leaves out characters that are easily mistaken for one
+// another when a code is read out loud or off a screen.
+const InviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+//GetSecureRandomString generates a random string of n characters from alphabet
+//using the system's cryptographically secure random source
+func GetSecureRandomString(n int, alphabet string) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		idx, err := srand.Int(srand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[idx.Int64()]
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,149 @@
+package identity
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Nerzal/gocloak"
+	oidc "github.com/coreos/go-oidc"
+)
+
+// KeycloakProvider is the IdentityProvider backed by a Keycloak realm.
+type KeycloakProvider struct {
+	client       gocloak.GoCloak
+	realm        string
+	clientID     string
+	clientSecret string
+	issuer       string
+	keys         oidc.KeySet
+
+	lock  sync.RWMutex
+	token *gocloak.JWT
+}
+
+// NewKeycloakProvider creates a provider for realm on the Keycloak server at
+// addr, issuer is the realm's OpenID Connect issuer URL and keys the realm's
+// signing keys.
+func NewKeycloakProvider(addr, realm, clientID, clientSecret, issuer string, keys oidc.KeySet) *KeycloakProvider {
+	return &KeycloakProvider{
+		client:       gocloak.NewClient(addr),
+		realm:        realm,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		issuer:       issuer,
+		keys:         keys,
+	}
+}
+
+// Handshake logs the service account in, it is called periodically to keep
+// the admin token fresh.
+func (k *KeycloakProvider) Handshake() error {
+	tkn, err := k.client.LoginClient(k.clientID, k.clientSecret, k.realm)
+	if err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	k.token = tkn
+	k.lock.Unlock()
+	return nil
+}
+
+func (k *KeycloakProvider) accessToken() string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.token.AccessToken
+}
+
+func (k *KeycloakProvider) NewAccount(username, email, password string) (string, error) {
+	user := gocloak.User{
+		Email:     email,
+		Enabled:   true,
+		Username:  username,
+		FirstName: username,
+	}
+
+	uuid, err := k.client.CreateUser(k.accessToken(), k.realm, user)
+	if err != nil {
+		return "", err
+	}
+
+	//HACK: hopefully future gocloak versions let me set
+	//      the password directly on the type struct
+	err = k.client.SetPassword(k.accessToken(), uuid, k.realm, password, false)
+
+	return uuid, err
+}
+
+func (k *KeycloakProvider) DeleteAccount(uuid string) error {
+	return notFound(k.client.DeleteUser(k.accessToken(), k.realm, uuid))
+}
+
+func (k *KeycloakProvider) LoginAccount(username, password string) (*gocloak.JWT, error) {
+	return k.client.Login(k.clientID, k.clientSecret, k.realm, username, password)
+}
+
+func (k *KeycloakProvider) GetAccount(uuid string) (*gocloak.User, error) {
+	u, err := k.client.GetUserByID(k.accessToken(), k.realm, uuid)
+	return u, notFound(err)
+}
+
+// FindAccount looks up an account by its exact username, Keycloak's own
+// username filter is a substring search.
+func (k *KeycloakProvider) FindAccount(username string) (*gocloak.User, error) {
+	users, err := k.client.GetUsers(k.accessToken(), k.realm, gocloak.GetUsersParams{Username: username})
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Username, username) {
+			return u, nil
+		}
+	}
+	return nil, ErrAccountNotFound
+}
+
+// RenameAccount needs the realm to allow editing usernames.
+func (k *KeycloakProvider) RenameAccount(uuid, username string) error {
+	if u, err := k.FindAccount(username); err == nil && u.ID != uuid {
+		return ErrAccountExists
+	} else if err != nil && err != ErrAccountNotFound {
+		return err
+	}
+	u, err := k.GetAccount(uuid)
+	if err != nil {
+		return err
+	}
+
+	// only the fields being changed are sent, Keycloak leaves the rest
+	user := gocloak.User{ID: uuid, Username: username}
+	if strings.EqualFold(u.FirstName, u.Username) {
+		user.FirstName = username
+	}
+	return notFound(k.client.UpdateUser(k.accessToken(), k.realm, user))
+}
+
+func (k *KeycloakProvider) GetGroups(uuid string) ([]*gocloak.UserGroup, error) {
+	return k.client.GetUserGroups(k.accessToken(), k.realm, uuid)
+}
+
+func (k *KeycloakProvider) RefreshToken(ref string) (*gocloak.JWT, error) {
+	return k.client.RefreshToken(ref, k.clientID, k.clientSecret, k.realm)
+}
+
+func (k *KeycloakProvider) Issuer() string {
+	return k.issuer
+}
+
+func (k *KeycloakProvider) KeySet() oidc.KeySet {
+	return k.keys
+}
+
+// notFound turns gocloak's bare status errors for missing users into
+// ErrAccountNotFound.
+func notFound(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "404") {
+		return ErrAccountNotFound
+	}
+	return err
+}
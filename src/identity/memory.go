@@ -0,0 +1,422 @@
+package identity
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nerzal/gocloak"
+	oidc "github.com/coreos/go-oidc"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	memoryAccessLifespan  = 5 * time.Minute
+	memoryRefreshLifespan = 30 * time.Minute
+	memoryDefaultIssuer   = "http://localhost:5000/idp"
+)
+
+type memoryUser struct {
+	gocloak.User
+	password string
+	groups   []string
+	scopes   []string
+}
+
+type memorySession struct {
+	user    string
+	expires time.Time
+}
+
+// MemoryProvider is an IdentityProvider that keeps users, groups, passwords
+// and issued tokens in memory. Access tokens are RS256 signed JWTs so they
+// can be verified like the ones Keycloak issues.
+type MemoryProvider struct {
+	issuer   string
+	clientID string
+	keyID    string
+	key      *rsa.PrivateKey
+	signer   jose.Signer
+
+	lock     sync.RWMutex
+	users    map[string]*memoryUser
+	groups   map[string]*gocloak.UserGroup
+	sessions map[string]memorySession
+}
+
+// MemorySeed is the JSON document MemoryProvider.Seed reads from disk.
+type MemorySeed struct {
+	Groups []string `json:"groups"`
+	Users  []struct {
+		Username string   `json:"username"`
+		Email    string   `json:"email"`
+		Password string   `json:"password"`
+		Groups   []string `json:"groups"`
+		Scopes   []string `json:"scopes"`
+	} `json:"users"`
+}
+
+func NewMemoryProvider(issuer, clientID string) (*MemoryProvider, error) {
+	if issuer == "" {
+		issuer = memoryDefaultIssuer
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemoryProvider{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		clientID: clientID,
+		keyID:    kid,
+		key:      key,
+		signer:   signer,
+		users:    make(map[string]*memoryUser),
+		groups:   make(map[string]*gocloak.UserGroup),
+		sessions: make(map[string]memorySession),
+	}, nil
+}
+
+// Seed loads groups and users from a MemorySeed JSON file.
+func (m *MemoryProvider) Seed(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	seed := new(MemorySeed)
+	if err := json.Unmarshal(data, seed); err != nil {
+		return err
+	}
+
+	for _, name := range seed.Groups {
+		if _, err := m.AddGroup(name); err != nil {
+			return err
+		}
+	}
+	for _, u := range seed.Users {
+		uuid, err := m.NewAccount(u.Username, u.Email, u.Password)
+		if err != nil {
+			return err
+		}
+		for _, name := range u.Groups {
+			if err := m.AddUserToGroup(uuid, name); err != nil {
+				return err
+			}
+		}
+		if err := m.GrantScopes(uuid, u.Scopes...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handshake is a no-op, there is no remote server to log into.
+func (m *MemoryProvider) Handshake() error {
+	return nil
+}
+
+// AddGroup creates a group, or returns the existing one with the same name.
+func (m *MemoryProvider) AddGroup(name string) (*gocloak.UserGroup, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, grp := range m.groups {
+		if grp.Name == name {
+			return grp, nil
+		}
+	}
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	grp := &gocloak.UserGroup{ID: id, Name: name, Path: "/" + name}
+	m.groups[id] = grp
+	return grp, nil
+}
+
+// AddUserToGroup adds an account to a group that was created with AddGroup.
+func (m *MemoryProvider) AddUserToGroup(uuid, group string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	u, ok := m.users[uuid]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	for id, grp := range m.groups {
+		if grp.Name == group {
+			for _, gid := range u.groups {
+				if gid == id {
+					return nil
+				}
+			}
+			u.groups = append(u.groups, id)
+			return nil
+		}
+	}
+	return ErrGroupNotFound
+}
+
+// GrantScopes adds scopes to the access tokens issued to an account from now on.
+func (m *MemoryProvider) GrantScopes(uuid string, scopes ...string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	u, ok := m.users[uuid]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	u.scopes = append(u.scopes, scopes...)
+	return nil
+}
+
+func (m *MemoryProvider) NewAccount(username, email, password string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, u := range m.users {
+		if strings.EqualFold(u.Username, username) ||
+			(email != "" && strings.EqualFold(u.Email, email)) {
+			return "", ErrAccountExists
+		}
+	}
+
+	uuid, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+	m.users[uuid] = &memoryUser{
+		User: gocloak.User{
+			ID:               uuid,
+			CreatedTimestamp: time.Now().Unix() * 1000,
+			Username:         strings.ToLower(username),
+			Enabled:          true,
+			FirstName:        username,
+			Email:            strings.ToLower(email),
+		},
+		password: password,
+	}
+	return uuid, nil
+}
+
+func (m *MemoryProvider) DeleteAccount(uuid string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.users[uuid]; !ok {
+		return ErrAccountNotFound
+	}
+	delete(m.users, uuid)
+	for ref, s := range m.sessions {
+		if s.user == uuid {
+			delete(m.sessions, ref)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryProvider) LoginAccount(username, password string) (*gocloak.JWT, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, u := range m.users {
+		if !strings.EqualFold(u.Username, username) && !strings.EqualFold(u.Email, username) {
+			continue
+		}
+		if !u.Enabled || subtle.ConstantTimeCompare([]byte(u.password), []byte(password)) != 1 {
+			break
+		}
+		return m.issue(u)
+	}
+	return nil, ErrInvalidCredentials
+}
+
+func (m *MemoryProvider) GetAccount(uuid string) (*gocloak.User, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	u, ok := m.users[uuid]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	acc := u.User
+	return &acc, nil
+}
+
+func (m *MemoryProvider) FindAccount(username string) (*gocloak.User, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, u := range m.users {
+		if strings.EqualFold(u.Username, username) {
+			acc := u.User
+			return &acc, nil
+		}
+	}
+	return nil, ErrAccountNotFound
+}
+
+func (m *MemoryProvider) RenameAccount(uuid, username string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	u, ok := m.users[uuid]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	for id, o := range m.users {
+		if id != uuid && strings.EqualFold(o.Username, username) {
+			return ErrAccountExists
+		}
+	}
+	if strings.EqualFold(u.FirstName, u.Username) {
+		u.FirstName = username
+	}
+	u.Username = strings.ToLower(username)
+	return nil
+}
+
+func (m *MemoryProvider) GetGroups(uuid string) ([]*gocloak.UserGroup, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	u, ok := m.users[uuid]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	grps := make([]*gocloak.UserGroup, 0, len(u.groups))
+	for _, id := range u.groups {
+		grp := *m.groups[id]
+		grps = append(grps, &grp)
+	}
+	return grps, nil
+}
+
+func (m *MemoryProvider) RefreshToken(ref string) (*gocloak.JWT, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	s, ok := m.sessions[ref]
+	if !ok {
+		return nil, ErrInvalidRefresh
+	}
+	// refresh tokens are single use
+	delete(m.sessions, ref)
+	if time.Now().After(s.expires) {
+		return nil, ErrInvalidRefresh
+	}
+	u, ok := m.users[s.user]
+	if !ok || !u.Enabled {
+		return nil, ErrInvalidRefresh
+	}
+	return m.issue(u)
+}
+
+func (m *MemoryProvider) Issuer() string {
+	return m.issuer
+}
+
+func (m *MemoryProvider) KeySet() oidc.KeySet {
+	return &StaticKeySet{Keys: []jose.JSONWebKey{{
+		Key:       &m.key.PublicKey,
+		KeyID:     m.keyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}}
+}
+
+// issue signs a new access token for u and opens a refresh session,
+// the caller must hold the write lock.
+func (m *MemoryProvider) issue(u *memoryUser) (*gocloak.JWT, error) {
+	now := time.Now()
+	grps := make([]string, 0, len(u.groups))
+	for _, id := range u.groups {
+		grps = append(grps, m.groups[id].Name)
+	}
+	jti, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{
+		"jti":                jti,
+		"iss":                m.issuer,
+		"aud":                m.clientID,
+		"azp":                m.clientID,
+		"sub":                u.ID,
+		"typ":                "Bearer",
+		"iat":                now.Unix(),
+		"exp":                now.Add(memoryAccessLifespan).Unix(),
+		"preferred_username": u.Username,
+		"email":              u.Email,
+		"groups":             grps,
+		"scope":              strings.Join(append([]string{"openid", "profile", "email"}, u.scopes...), " "),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := m.signer.Sign(payload)
+	if err != nil {
+		return nil, err
+	}
+	access, err := obj.CompactSerialize()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	ref := base64.RawURLEncoding.EncodeToString(buf)
+	m.sessions[ref] = memorySession{user: u.ID, expires: now.Add(memoryRefreshLifespan)}
+
+	return &gocloak.JWT{
+		AccessToken:      access,
+		ExpiresIn:        int(memoryAccessLifespan / time.Second),
+		RefreshExpiresIn: int(memoryRefreshLifespan / time.Second),
+		RefreshToken:     ref,
+		TokenType:        "bearer",
+		SessionState:     jti,
+		Scope:            claims["scope"].(string),
+	}, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// newUUID returns a random (version 4) UUID like the ones Keycloak hands out.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
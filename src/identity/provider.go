@@ -1,20 +1,100 @@
 package identity
 
 import (
+	"errors"
 	"os"
+	"strings"
 
 	"github.com/Nerzal/gocloak"
+	oidc "github.com/coreos/go-oidc"
 	"github.com/spidernest-go/logger"
 )
 
-var idp gocloak.GoCloak
-var token *gocloak.JWT
+const (
+	DriverKeycloak = "keycloak"
+	DriverMemory   = "memory"
+)
+
+var (
+	ErrAccountExists      = errors.New("Account with that username or email already exists.")
+	ErrAccountNotFound    = errors.New("Account does not exist.")
+	ErrGroupNotFound      = errors.New("Group does not exist.")
+	ErrInvalidCredentials = errors.New("Username or password is incorrect.")
+	ErrInvalidRefresh     = errors.New("Refresh token is invalid or expired.")
+)
+
+// IdentityProvider is the set of account operations the service needs from
+// an identity server. The Keycloak implementation is used in production,
+// the in-memory one lets the service run without any identity server.
+type IdentityProvider interface {
+	Handshake() error
+	NewAccount(username, email, password string) (string, error)
+	DeleteAccount(uuid string) error
+	LoginAccount(username, password string) (*gocloak.JWT, error)
+	GetAccount(uuid string) (*gocloak.User, error)
+	FindAccount(username string) (*gocloak.User, error)
+	RenameAccount(uuid, username string) error
+	GetGroups(uuid string) ([]*gocloak.UserGroup, error)
+	RefreshToken(ref string) (*gocloak.JWT, error)
+
+	// Issuer and KeySet describe the access tokens the provider hands
+	// out so they can be verified.
+	Issuer() string
+	KeySet() oidc.KeySet
+}
+
+// Provider is the identity provider every package level function in
+// identity talks to. It is selected by Handshake from IDP_DRIVER unless
+// it has already been set.
+var Provider IdentityProvider
+
+// Driver returns the identity provider driver selected by the environment.
+func Driver() string {
+	switch d := os.Getenv("IDP_DRIVER"); d {
+	case "":
+		return DriverKeycloak
+	default:
+		return d
+	}
+}
 
 func Handshake() {
-	var err error
-	idp = gocloak.NewClient(os.Getenv("IDP_ADDR"))
-	token, err = idp.LoginClient(os.Getenv("OIDC_CLIENT_ID"), os.Getenv("OIDC_CLIENT_SECRET"), os.Getenv("IDP_REALM"))
-	if err != nil {
+	if Provider == nil {
+		switch Driver() {
+		case DriverKeycloak:
+			issuer := strings.TrimSuffix(os.Getenv("OIDC_URL"), "/")
+			jwks := os.Getenv("OIDC_JWKS_URL")
+			if jwks == "" {
+				jwks = issuer + "/protocol/openid-connect/certs"
+			}
+			Provider = NewKeycloakProvider(os.Getenv("IDP_ADDR"),
+				os.Getenv("IDP_REALM"),
+				os.Getenv("OIDC_CLIENT_ID"),
+				os.Getenv("OIDC_CLIENT_SECRET"),
+				issuer,
+				NewCachedKeySet(jwks, os.Getenv("OIDC_JWKS_CACHE")))
+		case DriverMemory:
+			mp, err := NewMemoryProvider(os.Getenv("OIDC_URL"), os.Getenv("OIDC_CLIENT_ID"))
+			if err != nil {
+				logger.Fatal().
+					Err(err).
+					Msg("In-memory identity provider could not be created.")
+			}
+			if seed := os.Getenv("IDP_SEED"); seed != "" {
+				if err := mp.Seed(seed); err != nil {
+					logger.Fatal().
+						Err(err).
+						Msgf("In-memory identity provider could not be seeded from %s.", seed)
+				}
+			}
+			Provider = mp
+		default:
+			logger.Fatal().
+				Msgf("Identity provider driver (%s) is not supported.", Driver())
+		}
+	}
+
+	if err := Provider.Handshake(); err != nil {
 		logger.Fatal().
 			Err(err).
 			Msg("Handshake with IDP server failed.")
@@ -0,0 +1,256 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/spidernest-go/logger"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// keySetRefreshInterval rate limits how often an unknown key id can make
+// the cached key set go back to the identity server.
+const keySetRefreshInterval = time.Minute
+
+var (
+	ErrUnknownSigningKey = errors.New("Token was signed by an unknown key.")
+	ErrWrongAudience     = errors.New("Token was issued for another audience.")
+	ErrNotAccessToken    = errors.New("Token is not an access token.")
+
+	AccessVerifier *Verifier
+)
+
+// Claims are the parts of a verified access token handlers care about.
+type Claims struct {
+	Subject  string   `json:"sub"`
+	Username string   `json:"preferred_username"`
+	Email    string   `json:"email"`
+	Groups   []string `json:"groups"`
+	Scope    string   `json:"scope"`
+	// Type tells keycloak's access tokens ("Bearer") apart from its ID and
+	// refresh tokens, which are signed by the same key.
+	Type string `json:"typ"`
+	// AuthorizedParty is the client the token was issued to, keycloak
+	// leaves it out of the audience unless an audience mapper adds it.
+	AuthorizedParty string `json:"azp"`
+}
+
+// StaticKeySet is an oidc.KeySet over keys known ahead of time, such as the
+// in-memory provider's signing key or keys generated locally.
+type StaticKeySet struct {
+	Keys []jose.JSONWebKey
+}
+
+func (s *StaticKeySet) VerifySignature(ctx context.Context, raw string) ([]byte, error) {
+	jws, err := jose.ParseSigned(raw)
+	if err != nil {
+		return nil, err
+	}
+	return verifyWithKeys(jws, s.Keys)
+}
+
+// CachedKeySet is an oidc.KeySet fetched from a JWKS endpoint. Keys are
+// kept in memory and mirrored to disk so tokens can still be verified
+// while the identity server is unreachable.
+type CachedKeySet struct {
+	url  string
+	path string
+
+	lock      sync.RWMutex
+	keys      []jose.JSONWebKey
+	attempted time.Time
+}
+
+// NewCachedKeySet creates a key set for the JWKS at url, path is optional
+// and is where the keys are persisted between restarts.
+func NewCachedKeySet(url, path string) *CachedKeySet {
+	ks := &CachedKeySet{url: url, path: path}
+	if path == "" {
+		return ks
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn().
+				Err(err).
+				Msgf("Cached signing keys (%s) could not be read.", path)
+		}
+		return ks
+	}
+	set := new(jose.JSONWebKeySet)
+	if err := json.Unmarshal(data, set); err != nil {
+		logger.Warn().
+			Err(err).
+			Msgf("Cached signing keys (%s) are malformed.", path)
+		return ks
+	}
+	ks.keys = set.Keys
+	return ks
+}
+
+func (k *CachedKeySet) VerifySignature(ctx context.Context, raw string) ([]byte, error) {
+	jws, err := jose.ParseSigned(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	k.lock.RLock()
+	keys := k.keys
+	k.lock.RUnlock()
+	if payload, err := verifyWithKeys(jws, keys); err != ErrUnknownSigningKey {
+		return payload, err
+	}
+
+	// the key may have been rotated, go fetch the new set
+	keys, err = k.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return verifyWithKeys(jws, keys)
+}
+
+func (k *CachedKeySet) refresh(ctx context.Context) ([]jose.JSONWebKey, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if time.Since(k.attempted) < keySetRefreshInterval {
+		return k.keys, nil
+	}
+	k.attempted = time.Now()
+
+	req, err := http.NewRequest(http.MethodGet, k.url, nil)
+	if err != nil {
+		return k.keys, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Signing keys could not be fetched, falling back to cached keys.")
+		return k.keys, nil
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return k.keys, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return k.keys, fmt.Errorf("Signing keys could not be fetched: %s %s", resp.Status, data)
+	}
+	set := new(jose.JSONWebKeySet)
+	if err := json.Unmarshal(data, set); err != nil {
+		return k.keys, err
+	}
+	k.keys = set.Keys
+
+	if k.path != "" {
+		if err := ioutil.WriteFile(k.path, data, 0600); err != nil {
+			logger.Warn().
+				Err(err).
+				Msgf("Signing keys could not be cached to %s.", k.path)
+		}
+	}
+	return k.keys, nil
+}
+
+func verifyWithKeys(jws *jose.JSONWebSignature, keys []jose.JSONWebKey) ([]byte, error) {
+	kid := ""
+	if len(jws.Signatures) > 0 {
+		kid = jws.Signatures[0].Header.KeyID
+	}
+	for _, key := range keys {
+		if kid != "" && key.KeyID != kid {
+			continue
+		}
+		if payload, err := jws.Verify(key.Key); err == nil {
+			return payload, nil
+		}
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+// Verifier checks access tokens against an issuer, its signing keys and
+// an audience.
+type Verifier struct {
+	audience string
+	tokens   *oidc.IDTokenVerifier
+}
+
+// NewAccessVerifier builds a verifier checking the signature against keys,
+// the issuer, the audience and the expiry of access tokens.
+func NewAccessVerifier(issuer, audience string, keys oidc.KeySet) *Verifier {
+	return &Verifier{
+		audience: audience,
+		// the audience is checked by Verify, since it may be in azp
+		tokens: oidc.NewVerifier(issuer, keys, &oidc.Config{SkipClientIDCheck: true}),
+	}
+}
+
+// Verify checks a raw token and returns its claims. A token is for the
+// audience if it is listed in aud or, as keycloak does for the client the
+// token was requested by, in azp.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	tkn, err := v.tokens.Verify(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := new(Claims)
+	if err := tkn.Claims(claims); err != nil {
+		return nil, err
+	}
+	if claims.Type != "Bearer" {
+		return nil, ErrNotAccessToken
+	}
+	if v.audience != "" && claims.AuthorizedParty != v.audience && !contains(tkn.Audience, v.audience) {
+		return nil, ErrWrongAudience
+	}
+	// keycloak's group mapper hands out full paths
+	for i := range claims.Groups {
+		claims.Groups[i] = strings.TrimPrefix(claims.Groups[i], "/")
+	}
+	return claims, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableVerification sets up AccessVerifier against the selected Provider,
+// Handshake must have been called first.
+func EnableVerification() {
+	audience := os.Getenv("OIDC_AUDIENCE")
+	if audience == "" {
+		audience = os.Getenv("OIDC_CLIENT_ID")
+	}
+	if audience == "" {
+		logger.Warn().
+			Msg("No audience is configured, access tokens for any client will be accepted.")
+	}
+
+	AccessVerifier = NewAccessVerifier(Provider.Issuer(), audience, Provider.KeySet())
+
+	logger.Info().
+		Msg("Access token verification loaded successfully.")
+}
+
+// VerifyAccessToken checks a raw bearer token and returns its claims.
+func VerifyAccessToken(ctx context.Context, raw string) (*Claims, error) {
+	return AccessVerifier.Verify(ctx, raw)
+}
@@ -0,0 +1,131 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	testIssuer   = "https://id.example.com/auth/realms/orchestra"
+	testAudience = "profiles"
+)
+
+type testKey struct {
+	signer jose.Signer
+	public jose.JSONWebKey
+}
+
+func newTestKey(t *testing.T, kid string) *testKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKey{
+		signer: signer,
+		public: jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"},
+	}
+}
+
+func (k *testKey) sign(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := k.signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func testClaims(change func(map[string]interface{})) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":                testIssuer,
+		"aud":                testAudience,
+		"sub":                "f2a1c3d4-0000-4000-8000-000000000001",
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"preferred_username": "conductor",
+		"groups":             []string{"/staff"},
+		"scope":              "profile email",
+		"typ":                "Bearer",
+	}
+	if change != nil {
+		change(claims)
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	key := newTestKey(t, "test")
+	v := NewAccessVerifier(testIssuer, testAudience, &StaticKeySet{Keys: []jose.JSONWebKey{key.public}})
+
+	for _, tc := range []struct {
+		name   string
+		change func(map[string]interface{})
+		ok     bool
+	}{
+		{"good", nil, true},
+		{"audience list", func(c map[string]interface{}) { c["aud"] = []string{"account", testAudience} }, true},
+		{"keycloak azp", func(c map[string]interface{}) { c["aud"] = "account"; c["azp"] = testAudience }, true},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com/auth/realms/orchestra" }, false},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "account"; c["azp"] = "another-client" }, false},
+		{"expired", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, false},
+		{"id token", func(c map[string]interface{}) { c["typ"] = "ID" }, false},
+		{"refresh token", func(c map[string]interface{}) { c["typ"] = "Refresh" }, false},
+		{"no type", func(c map[string]interface{}) { delete(c, "typ") }, false},
+	} {
+		claims, err := v.Verify(context.Background(), key.sign(t, testClaims(tc.change)))
+		if !tc.ok {
+			if err == nil {
+				t.Errorf("%s: token was accepted", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if claims.Username != "conductor" || claims.Scope != "profile email" {
+			t.Errorf("%s: got claims %+v", tc.name, claims)
+		}
+		if len(claims.Groups) != 1 || claims.Groups[0] != "staff" {
+			t.Errorf("%s: got groups %v, want [staff]", tc.name, claims.Groups)
+		}
+	}
+}
+
+func TestVerifyWithoutAudience(t *testing.T) {
+	key := newTestKey(t, "test")
+	v := NewAccessVerifier(testIssuer, "", &StaticKeySet{Keys: []jose.JSONWebKey{key.public}})
+
+	raw := key.sign(t, testClaims(func(c map[string]interface{}) { c["aud"] = "anything" }))
+	if _, err := v.Verify(context.Background(), raw); err != nil {
+		t.Errorf("token for any audience was rejected: %v", err)
+	}
+}
+
+func TestVerifyUnknownKey(t *testing.T) {
+	key, other := newTestKey(t, "test"), newTestKey(t, "other")
+	v := NewAccessVerifier(testIssuer, testAudience, &StaticKeySet{Keys: []jose.JSONWebKey{key.public}})
+
+	if _, err := v.Verify(context.Background(), other.sign(t, testClaims(nil))); err == nil {
+		t.Error("token signed by an unknown key was accepted")
+	}
+}
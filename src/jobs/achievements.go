@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/orchestrafm/profiles/src/achievements"
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/logger"
+)
+
+// streakWithin bounds how far back play days are looked at for streaks.
+const streakWithin = 366 * 24 * time.Hour
+
+// UnlockAchievements evaluates the catalog against the profile's current
+// stats and stores the achievements it newly earned.
+func UnlockAchievements(pf *database.Profile) ([]achievements.Achievement, error) {
+	us, err := database.SelectUnlocks(pf.ID)
+	if err != nil {
+		return nil, err
+	}
+	unlocked := make(map[string]bool, len(us))
+	for _, u := range us {
+		unlocked[u.Achievement] = true
+	}
+
+	c := achievements.Active
+	s := achievements.Stats{
+		achievements.StatLevel:             pf.Level,
+		achievements.StatPlayCount:         pf.PlayCount,
+		achievements.StatTotalScore:        pf.TotalScore,
+		achievements.StatPerformanceRating: pf.PerformanceRating,
+		achievements.StatMastery:           uint64(pf.Mastery),
+	}
+	if c.Needs(achievements.StatClears, unlocked) ||
+		c.Needs(achievements.StatFullCombos, unlocked) ||
+		c.Needs(achievements.StatPerfects, unlocked) {
+		cleared, fc, perfect, err := database.BoardCounts(pf.ID)
+		if err != nil {
+			return nil, err
+		}
+		s[achievements.StatClears] = cleared
+		s[achievements.StatFullCombos] = fc
+		s[achievements.StatPerfects] = perfect
+	}
+	if c.Needs(achievements.StatStreak, unlocked) {
+		now := time.Now()
+		days, err := database.PlayDays(pf.ID, now.Add(-streakWithin))
+		if err != nil {
+			return nil, err
+		}
+		s[achievements.StatStreak] = achievements.Streak(days, now)
+	}
+
+	earned := c.Evaluate(s, unlocked)
+	now := time.Now()
+	for _, a := range earned {
+		u := &database.Unlock{ProfileID: pf.ID, Achievement: a.ID, Unlocked: now}
+		if err := database.SaveUnlock(u); err != nil {
+			return nil, err
+		}
+	}
+	return earned, nil
+}
+
+// unlockAll evaluates the catalog for every profile, recomputing levels or
+// ratings can earn achievements without anyone playing.
+func unlockAll() error {
+	var last, n uint64
+	for {
+		ps, err := database.SelectProfiles(last, 100)
+		if err != nil {
+			return err
+		}
+		if len(ps) == 0 {
+			break
+		}
+		for i := range ps {
+			last = ps[i].ID
+			earned, err := UnlockAchievements(&ps[i])
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Msgf("Achievements of profile %d could not be unlocked.", ps[i].ID)
+				return err
+			}
+			if len(earned) > 0 {
+				n++
+			}
+		}
+	}
+
+	logger.Info().
+		Msgf("Achievements were unlocked for %d profiles.", n)
+	return nil
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/media"
+	"github.com/spidernest-go/logger"
+)
+
+// DeleteAccount carries a deletion as far as it can, it is safe to call
+// again on the same tombstone after any step failed.
+func DeleteAccount(t *database.Tombstone) error {
+	if !t.DataRemoved {
+		email := ""
+		if acc, err := identity.GetAccount(t.UUID); err == nil {
+			email = acc.Email
+		} else if err != identity.ErrAccountNotFound {
+			return err
+		}
+
+		if err := removeExports(t.ID); err != nil {
+			return err
+		}
+		if err := media.Purge(t.ID); err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Images of profile %d could not be removed.", t.ID)
+			return err
+		}
+		if err := t.RemoveData(email); err != nil {
+			return err
+		}
+	}
+
+	if !t.IdentityRemoved {
+		err := identity.DeleteAccount(t.UUID)
+		if err != nil && err != identity.ErrAccountNotFound {
+			logger.Error().
+				Err(err).
+				Msgf("Account of profile %d could not be removed from the IDP.", t.ID)
+			return err
+		}
+
+		if err := t.MarkIdentityRemoved(); err != nil {
+			return err
+		}
+	}
+
+	logger.Info().
+		Msgf("Profile %d was deleted.", t.ID)
+	return nil
+}
+
+// ResumeDeletions retries every deletion that did not complete.
+func ResumeDeletions() {
+	ts, err := database.PendingTombstones()
+	if err != nil {
+		return
+	}
+	for i := range ts {
+		// failures are logged and picked up on the next run
+		DeleteAccount(&ts[i])
+	}
+}
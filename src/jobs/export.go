@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/media"
+	"github.com/spidernest-go/logger"
+)
+
+// exportLifespan is how long an archive waits to be downloaded.
+const exportLifespan = 72 * time.Hour
+
+// ExportDir is where personal data archives are written, set by EXPORT_DIR.
+func ExportDir() string {
+	if dir := os.Getenv("EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "profiles-exports")
+}
+
+func exportPath(e *database.Export) string {
+	return filepath.Join(ExportDir(), strconv.FormatUint(e.ID, 10)+".zip")
+}
+
+// StartExport records a new export for the profile and builds its archive
+// in the background. The returned token is the only way to download it.
+func StartExport(pf *database.Profile) (string, *database.Export, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	e := &database.Export{
+		ProfileID: pf.ID,
+		TokenHash: database.HashExportToken(token),
+		Status:    database.ExportPending,
+		Expires:   time.Now().Add(exportLifespan),
+	}
+	if err := e.New(); err != nil {
+		return "", nil, err
+	}
+
+	go func() {
+		if err := buildExport(e, pf); err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Export of profile %d failed.", pf.ID)
+
+			e.SetStatus(database.ExportPending, database.ExportFailed)
+			return
+		}
+		// an export given up on while building has no row left to hand out its archive
+		if ok, err := e.SetStatus(database.ExportPending, database.ExportReady); err == nil && !ok {
+			os.Remove(exportPath(e))
+		}
+	}()
+
+	return token, e, nil
+}
+
+// buildExport writes everything held about the profile into its archive.
+func buildExport(e *database.Export, pf *database.Profile) error {
+	acc, err := identity.GetAccount(pf.UUID)
+	if err != nil {
+		return err
+	}
+	grps, err := identity.GetGroups(pf.UUID)
+	if err != nil {
+		return err
+	}
+	var list struct {
+		Entry   *database.ReqList  `json:"entry"`
+		Consent []database.Consent `json:"consent"`
+	}
+	if acc.Email != "" {
+		// not being on the mailing list is not an error
+		_, list.Entry = database.SelectReqList(acc.Email)
+		if list.Consent, err = database.SelectConsent(acc.Email); err != nil {
+			return err
+		}
+	}
+
+	issued, err := database.SelectInvitesIssuedBy(pf.ID)
+	if err != nil {
+		return err
+	}
+	redeemed, err := database.SelectRedemptionsBy(pf.ID)
+	if err != nil {
+		return err
+	}
+	plays, err := database.SelectPlaysOf(pf.ID)
+	if err != nil {
+		return err
+	}
+	modes, err := database.SelectAllModeStats(pf.ID)
+	if err != nil {
+		return err
+	}
+	history, err := database.SelectAllHistory(pf.ID)
+	if err != nil {
+		return err
+	}
+	unlocks, err := database.SelectUnlocks(pf.ID)
+	if err != nil {
+		return err
+	}
+	followers, following, err := database.SelectFollowsOf(pf.ID)
+	if err != nil {
+		return err
+	}
+	blocks, err := database.SelectAllBlocked(pf.ID)
+	if err != nil {
+		return err
+	}
+	names, err := database.SelectPreviousNames(pf.ID, true)
+	if err != nil {
+		return err
+	}
+	pf.Avatar = media.URLs(media.Avatar, pf.ID, pf.AvatarFile)
+	pf.Banner = media.URLs(media.Banner, pf.ID, pf.BannerFile)
+	invites := struct {
+		Issued   []database.Invite     `json:"issued"`
+		Redeemed []database.Redemption `json:"redeemed"`
+	}{issued, redeemed}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", pf},
+		{"account.json", acc},
+		{"groups.json", grps},
+		{"invites.json", invites},
+		{"plays.json", plays},
+		{"mode_stats.json", modes},
+		{"history.json", history},
+		{"achievements.json", unlocks},
+		{"follows.json", struct {
+			Followers []database.Connection `json:"followers"`
+			Following []database.Connection `json:"following"`
+		}{followers, following}},
+		{"blocks.json", blocks},
+		{"usernames.json", names},
+		{"mailing_list.json", list},
+	}
+
+	if err := os.MkdirAll(ExportDir(), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(exportPath(e), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	z := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := z.Create(file.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// ReadExport returns the archive and marks it as downloaded, it can only
+// be read once.
+func ReadExport(e *database.Export) ([]byte, error) {
+	data, err := ioutil.ReadFile(exportPath(e))
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := e.SetStatus(database.ExportReady, database.ExportFetched); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	os.Remove(exportPath(e))
+	return data, nil
+}
+
+// CleanExports throws away archives that expired, failed or were downloaded.
+func CleanExports() {
+	es, err := database.SelectExpiredExports()
+	if err != nil {
+		return
+	}
+	for i := range es {
+		removeExport(&es[i])
+	}
+}
+
+// removeExports throws away every archive of a profile.
+func removeExports(profile uint64) error {
+	es, err := database.SelectExportsOf(profile)
+	if err != nil {
+		return err
+	}
+	for i := range es {
+		if err := removeExport(&es[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeExport(e *database.Export) error {
+	if err := os.Remove(exportPath(e)); err != nil && !os.IsNotExist(err) {
+		logger.Error().
+			Err(err).
+			Msgf("Export archive %s could not be removed.", exportPath(e))
+		return err
+	}
+	return e.Delete()
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/spidernest-go/logger"
+)
+
+// BackfillHandles caches the username of every profile made before
+// usernames were stored, so listings never have to ask the identity
+// provider.
+func BackfillHandles() {
+	var last uint64
+	for {
+		ps, err := database.SelectProfilesWithoutHandle(last, 100)
+		if err != nil || len(ps) == 0 {
+			return
+		}
+		for i := range ps {
+			last = ps[i].ID
+			acc, err := identity.GetAccount(ps[i].UUID)
+			if err != nil {
+				logger.Warn().
+					Err(err).
+					Msgf("Username of profile %d could not be looked up.", ps[i].ID)
+				continue
+			}
+			ps[i].SetHandle(acc.Username)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/logger"
+)
+
+const (
+	// historySetting holds the last day snapshots were taken for.
+	historySetting = "history_day"
+
+	// activeWithin is how recently a profile must have played to be
+	// snapshotted.
+	activeWithin = 30 * 24 * time.Hour
+
+	// Snapshots are kept daily for historyDaily, weekly until historyWeekly
+	// and monthly after that.
+	historyDaily  = 90 * 24 * time.Hour
+	historyWeekly = 2 * 365 * 24 * time.Hour
+)
+
+// SnapshotHistory records the standing of every active profile, at most
+// once a day. The first run after midnight UTC captures how the day that
+// just ended closed, so the snapshot is labelled with that day rather than
+// the one it runs on. Ranks are taken from the global performance
+// leaderboard.
+func SnapshotHistory() {
+	now := time.Now()
+	captured := database.Day(now).AddDate(0, 0, -1)
+	day := captured.Format("2006-01-02")
+	if last, err := database.GetSetting(historySetting); err != nil || last == day {
+		return
+	}
+
+	active, err := database.ActiveProfiles(now.Add(-activeWithin))
+	if err != nil {
+		return
+	}
+	b, err := database.NewBoard("performance_rating")
+	if err != nil {
+		return
+	}
+
+	var (
+		after *database.Cursor
+		n     int
+	)
+	for {
+		ss, err := b.Page(after, 500)
+		if err != nil {
+			return
+		}
+		for i := range ss {
+			if !active[ss[i].ID] {
+				continue
+			}
+			err := database.SaveSnapshot(&database.Snapshot{
+				ProfileID:         ss[i].ID,
+				Day:               captured,
+				PerformanceRating: ss[i].PerformanceRating,
+				GlobalRank:        ss[i].Rank,
+				Level:             ss[i].Level,
+				PlayCount:         ss[i].PlayCount,
+			})
+			if err != nil {
+				return
+			}
+			n++
+		}
+		if len(ss) < 500 {
+			break
+		}
+		after = b.Next(&ss[len(ss)-1])
+	}
+
+	logger.Info().
+		Msgf("History of %d profiles was recorded.", n)
+	database.PutSetting(historySetting, day)
+	database.DownsampleHistory(now.Add(-historyDaily), now.Add(-historyWeekly))
+}
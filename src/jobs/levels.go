@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/progression"
+	"github.com/spidernest-go/logger"
+)
+
+// levelCurveSetting holds the fingerprint of the curve stored levels were
+// last derived with.
+const levelCurveSetting = "level_curve"
+
+// SyncLevels recomputes every stored level if the active curve differs from
+// the one they were derived with.
+func SyncLevels() {
+	fp := progression.Active.Fingerprint()
+	stored, err := database.GetSetting(levelCurveSetting)
+	if err != nil || stored == fp {
+		return
+	}
+
+	logger.Info().
+		Msg("Level curve changed, recomputing levels.")
+	RecomputeLevels()
+}
+
+// RecomputeLevels derives every stored level from the active curve and
+// unlocks the achievements the new levels earn.
+func RecomputeLevels() (uint64, error) {
+	n, err := database.RecomputeLevels(progression.Active)
+	if err != nil {
+		return n, err
+	}
+	logger.Info().
+		Msgf("Levels of %d profiles were recomputed.", n)
+	if err := database.PutSetting(levelCurveSetting, progression.Active.Fingerprint()); err != nil {
+		return n, err
+	}
+	return n, unlockAll()
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/mail"
+	"github.com/spidernest-go/logger"
+)
+
+// confirmLifespan is how long a mailing list entry waits to be confirmed
+// before it is thrown away.
+const confirmLifespan = 48 * time.Hour
+
+var confirmMail = template.Must(template.New("confirm").Parse(`Hi,
+
+Someone, hopefully you, asked to join the Orchestra FM waitlist with this
+address. Confirm within 48 hours to take your place in the queue:
+
+    {{.Confirm}}
+
+If it wasn't you, ignore this mail and the address is forgotten.
+`))
+
+var legacyConfirmMail = template.Must(template.New("legacy").Parse(`Hi,
+
+This address joined the Orchestra FM waitlist before we asked everyone to
+confirm their address. To keep your place in the queue, confirm within 48
+hours:
+
+    {{.Confirm}}
+
+If you no longer want to hear from us, ignore this mail and the address is
+forgotten.
+`))
+
+// PublicURL is where the API is reachable from outside, set by PUBLIC_URL.
+func PublicURL() string {
+	if u := os.Getenv("PUBLIC_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:5000"
+}
+
+func confirmLink(email string) string {
+	return PublicURL() + "/api/v0/invite/confirm?token=" +
+		url.QueryEscape(mail.SignToken(mail.PurposeConfirm, email, confirmLifespan))
+}
+
+func unsubscribeLink(email string) string {
+	return PublicURL() + "/api/v0/invite/unsubscribe?token=" +
+		url.QueryEscape(mail.SignToken(mail.PurposeUnsubscribe, email, 0))
+}
+
+// listMail addresses a mailing list message to the entry, every one of them
+// carries an unsubscribe link.
+func listMail(email, subject, body string) *mail.Message {
+	link := unsubscribeLink(email)
+	return &mail.Message{
+		To:      email,
+		Subject: subject,
+		Body:    body + "\n--\nUnsubscribe: " + link + "\n",
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + link + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
+
+// SendConfirmation mails the link that confirms the entry.
+func SendConfirmation(r *database.ReqList) error {
+	body := new(bytes.Buffer)
+	if err := confirmMail.Execute(body, struct{ Confirm string }{confirmLink(r.Email)}); err != nil {
+		return err
+	}
+	return mail.Send(listMail(r.Email, "Confirm your place on the Orchestra FM waitlist", body.String()))
+}
+
+// RemindLegacy asks entries that joined before confirmation was required
+// to confirm, a batch at a time. Entries whose mail could not be sent are
+// tried again on the next run.
+func RemindLegacy() {
+	rs, err := database.SelectUnremindedLegacy(100)
+	if err != nil {
+		return
+	}
+	for i := range rs {
+		body := new(bytes.Buffer)
+		err := legacyConfirmMail.Execute(body, struct{ Confirm string }{confirmLink(rs[i].Email)})
+		if err == nil {
+			err = mail.Send(listMail(rs[i].Email, "Confirm your place on the Orchestra FM waitlist", body.String()))
+		}
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Legacy entry %s could not be asked to confirm.", rs[i].Email)
+			continue
+		}
+		rs[i].MarkReminded()
+	}
+}
+
+// CleanUnconfirmed throws away entries that were never confirmed.
+func CleanUnconfirmed() {
+	database.RemoveUnconfirmed(time.Now().Add(-confirmLifespan))
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/performance"
+	"github.com/spidernest-go/logger"
+)
+
+// performanceSetting holds the version of the algorithm stored performance
+// values were last computed with.
+const performanceSetting = "performance_version"
+
+// SyncPerformance recomputes every performance value and rating if they
+// were computed with another version of the algorithm.
+func SyncPerformance() {
+	stored, err := database.GetSetting(performanceSetting)
+	if err != nil || stored == performance.Version {
+		return
+	}
+
+	logger.Info().
+		Msg("Performance algorithm changed, recomputing ratings.")
+	RecomputePerformance()
+}
+
+// RecomputePerformance computes every play's performance and every rating
+// with the current algorithm, then unlocks the achievements they earn.
+func RecomputePerformance() (uint64, error) {
+	n, err := database.RecomputePerformance()
+	if err != nil {
+		return n, err
+	}
+	logger.Info().
+		Msgf("Ratings of %d profiles were recomputed.", n)
+	if err := database.PutSetting(performanceSetting, performance.Version); err != nil {
+		return n, err
+	}
+	return n, unlockAll()
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"bytes"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/mail"
+	"github.com/spidernest-go/logger"
+)
+
+// waitlistInviteLifespan is how long an invite sent to the waitlist can be
+// redeemed.
+const waitlistInviteLifespan = 14 * 24 * time.Hour
+
+var inviteMail = template.Must(template.New("invite").Parse(`Hi,
+
+A spot opened up for you on Orchestra FM. Register with the invite code
+
+    {{.Code}}
+{{if .URL}}
+at {{.URL}}
+{{end}}
+The code can be used once and expires on {{.Expires.Format "January 2, 2006"}}.
+`))
+
+// InviteWaitlist sends invites to the n entries at the front of the waitlist
+// and returns the entries that were invited. Entries whose mail could not be
+// sent keep their place in the queue.
+func InviteWaitlist(n int) ([]database.ReqList, error) {
+	rs, err := database.OldestWaitlisted(n)
+	if err != nil {
+		return nil, err
+	}
+
+	invited := make([]database.ReqList, 0, len(rs))
+	for i := range rs {
+		ok, err := inviteEntry(&rs[i])
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Waitlist entry %s could not be invited.", rs[i].Email)
+			continue
+		}
+		if ok {
+			invited = append(invited, rs[i])
+		}
+	}
+	return invited, nil
+}
+
+// inviteEntry reports false without an error when the entry was invited
+// concurrently.
+func inviteEntry(r *database.ReqList) (bool, error) {
+	expires := time.Now().Add(waitlistInviteLifespan)
+	invs, err := database.NewInvites(1, database.Invite{Expires: &expires, MaxUses: 1}, func() (string, error) {
+		return identity.GetSecureRandomString(database.InviteCodeLength, identity.InviteAlphabet)
+	})
+	if err != nil {
+		return false, err
+	}
+	inv := invs[0]
+
+	if ok, err := r.MarkInvited(inv.ID); err != nil || !ok {
+		database.RevokeInvite(inv.Code)
+		return false, err
+	}
+
+	body := new(bytes.Buffer)
+	err = inviteMail.Execute(body, struct {
+		Code    string
+		URL     string
+		Expires time.Time
+	}{inv.Code, os.Getenv("REGISTER_URL"), expires})
+	if err == nil {
+		err = mail.Send(listMail(r.Email, "Your Orchestra FM invite", body.String()))
+	}
+	if err != nil {
+		r.Unmark()
+		database.RevokeInvite(inv.Code)
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,166 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spidernest-go/logger"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverStdout = "stdout"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	Headers map[string]string
+}
+
+// Mailer delivers messages, SMTP in production and a local sink while
+// developing.
+type Mailer interface {
+	Send(m *Message) error
+}
+
+// Outbox is the Mailer every package sends through, it is selected by
+// Configure from MAIL_DRIVER unless it has already been set.
+var Outbox Mailer
+
+// Driver returns the mail driver selected by the environment.
+func Driver() string {
+	switch d := os.Getenv("MAIL_DRIVER"); d {
+	case "":
+		return DriverStdout
+	default:
+		return d
+	}
+}
+
+func Configure() {
+	if Outbox != nil {
+		return
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Orchestra FM <noreply@localhost>"
+	}
+
+	switch Driver() {
+	case DriverSMTP:
+		Outbox = &SMTPMailer{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     from,
+		}
+	case DriverFile:
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "profiles-mail")
+		}
+		Outbox = &FileMailer{Dir: dir, From: from}
+	case DriverStdout:
+		Outbox = &WriterMailer{W: os.Stdout, From: from}
+	default:
+		logger.Fatal().
+			Msgf("Mail driver (%s) is not supported.", Driver())
+	}
+
+	logger.Info().
+		Msgf("Mail is delivered through the %s driver.", Driver())
+}
+
+func Send(m *Message) error {
+	return Outbox.Send(m)
+}
+
+// SMTPMailer delivers through an SMTP relay, authenticating with PLAIN auth
+// when a username is set.
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPMailer) Send(m *Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host := s.Addr
+		if i := strings.LastIndexByte(host, ':'); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	return smtp.SendMail(s.Addr, auth, address(s.From), []string{m.To}, render(s.From, m))
+}
+
+// FileMailer writes every message to its own .eml file in Dir.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (f *FileMailer) Send(m *Message) error {
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("/", "_", "@", "_at_").Replace(m.To))
+	return ioutil.WriteFile(filepath.Join(f.Dir, name), render(f.From, m), 0600)
+}
+
+// WriterMailer prints every message to W.
+type WriterMailer struct {
+	W    io.Writer
+	From string
+
+	lock sync.Mutex
+}
+
+func (w *WriterMailer) Send(m *Message) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, err := w.W.Write(append(render(w.From, m), '\n'))
+	return err
+}
+
+func render(from string, m *Message) []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	fmt.Fprintf(buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	for k, v := range m.Headers {
+		fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.Replace(m.Body, "\n", "\r\n", -1))
+	return buf.Bytes()
+}
+
+// address extracts the bare address out of a "Name <address>" sender.
+func address(from string) string {
+	if i := strings.LastIndexByte(from, '<'); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
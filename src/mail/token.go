@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spidernest-go/logger"
+)
+
+const (
+	PurposeConfirm     = "confirm"
+	PurposeUnsubscribe = "unsubscribe"
+)
+
+var (
+	ErrTokenInvalid = errors.New("Token is invalid.")
+	ErrTokenExpired = errors.New("Token has expired.")
+)
+
+var (
+	secret     []byte
+	secretOnce sync.Once
+)
+
+// signingSecret is MAIL_TOKEN_SECRET, without it a random secret is used and
+// links sent before a restart stop working.
+func signingSecret() []byte {
+	secretOnce.Do(func() {
+		if s := os.Getenv("MAIL_TOKEN_SECRET"); s != "" {
+			secret = []byte(s)
+			return
+		}
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			logger.Fatal().
+				Err(err).
+				Msg("Mail token secret could not be generated.")
+		}
+		logger.Warn().
+			Msg("MAIL_TOKEN_SECRET is not set, links in sent mail only work until the service restarts.")
+	})
+	return secret
+}
+
+// SignToken binds email to purpose, the token expires after ttl unless ttl
+// is zero.
+func SignToken(purpose, email string, ttl time.Duration) string {
+	var exp int64
+	if ttl != 0 {
+		exp = time.Now().Add(ttl).Unix()
+	}
+	payload := purpose + "\n" + email + "\n" + strconv.FormatInt(exp, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac(payload))
+}
+
+// VerifyToken returns the email a token for purpose was signed for.
+func VerifyToken(purpose, token string) (string, error) {
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return "", ErrTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[:i])
+	if err != nil {
+		return "", ErrTokenInvalid
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !hmac.Equal(sum, mac(string(payload))) {
+		return "", ErrTokenInvalid
+	}
+
+	parts := strings.Split(string(payload), "\n")
+	if len(parts) != 3 || parts[0] != purpose {
+		return "", ErrTokenInvalid
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", ErrTokenInvalid
+	}
+	if exp != 0 && time.Now().Unix() > exp {
+		return "", ErrTokenExpired
+	}
+	return parts[1], nil
+}
+
+func mac(payload string) []byte {
+	h := hmac.New(sha256.New, signingSecret())
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
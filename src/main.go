@@ -1,10 +1,17 @@
 package main
 
 import (
+	"os"
+	"strconv"
 	"time"
 
+	"github.com/orchestrafm/profiles/src/achievements"
 	"github.com/orchestrafm/profiles/src/database"
 	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/jobs"
+	"github.com/orchestrafm/profiles/src/mail"
+	"github.com/orchestrafm/profiles/src/media"
+	"github.com/orchestrafm/profiles/src/progression"
 	"github.com/orchestrafm/profiles/src/routers"
 	"github.com/spidernest-go/logger"
 )
@@ -17,6 +24,12 @@ func main() {
 			Msg("MySQL Database could not be attached to.")
 	}
 	database.Synchronize()
+	progression.Load()
+	achievements.Load()
+	go func() {
+		jobs.SyncLevels()
+		jobs.SyncPerformance()
+	}()
 
 	identity.Handshake()
 	job := time.NewTicker(time.Minute)
@@ -27,7 +40,44 @@ func main() {
 		}
 	}()
 
-	identity.EnableOIDC()
+	if identity.Driver() == identity.DriverKeycloak {
+		identity.EnableOIDC()
+	}
+	identity.EnableVerification()
+
+	mail.Configure()
+	media.Configure()
+	if n, _ := strconv.Atoi(os.Getenv("WAITLIST_DAILY_INVITES")); n > 0 {
+		daily := time.NewTicker(24 * time.Hour)
+		go func() {
+			for {
+				<-daily.C
+				jobs.InviteWaitlist(n)
+			}
+		}()
+	}
+
+	go jobs.BackfillHandles()
+	go jobs.SnapshotHistory()
+	history := time.NewTicker(time.Hour)
+	go func() {
+		for {
+			<-history.C
+			jobs.SnapshotHistory()
+		}
+	}()
+
+	jobs.ResumeDeletions()
+	cleanup := time.NewTicker(10 * time.Minute)
+	go func() {
+		for {
+			<-cleanup.C
+			jobs.ResumeDeletions()
+			jobs.CleanExports()
+			jobs.RemindLegacy()
+			jobs.CleanUnconfirmed()
+		}
+	}()
 
 	routers.ListenAndServe()
 }
@@ -0,0 +1,214 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Size is a variant every upload is resized to, cropping it to the same
+// aspect ratio first.
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Kind is a type of profile image. MaxBytes stays within the server's 4 MiB
+// request body limit, which applies to every route.
+type Kind struct {
+	Name     string
+	MaxBytes int
+	Sizes    []Size
+}
+
+var (
+	Avatar = Kind{
+		Name:     "avatar",
+		MaxBytes: 2 << 20,
+		Sizes:    []Size{{"large", 512, 512}, {"medium", 256, 256}, {"small", 64, 64}},
+	}
+	Banner = Kind{
+		Name:     "banner",
+		MaxBytes: 4 << 20,
+		Sizes:    []Size{{"large", 1500, 500}, {"small", 750, 250}},
+	}
+)
+
+// maxPixels bounds the decoded size of an upload, so small files can't
+// expand into huge images. It leaves plenty of room above a 1500x500 banner.
+const maxPixels = 16000000
+
+// processing bounds how many uploads are decoded at once, each can take up
+// to 64 MB while it is.
+var processing = make(chan struct{}, 2)
+
+// jpegQuality is used for images without transparency.
+const jpegQuality = 90
+
+var formats = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/webp": "webp",
+}
+
+var (
+	ErrTooLarge    = errors.New("Image is too large.")
+	ErrUnsupported = errors.New("Image must be a PNG, JPEG or WebP.")
+	ErrDimensions  = errors.New("Image dimensions are too large.")
+	ErrCorrupt     = errors.New("Image could not be decoded.")
+)
+
+// Image is an upload resized to every size of its kind. Every variant is
+// encoded anew, so none of the upload's metadata survives.
+type Image struct {
+	Kind     Kind
+	Version  string
+	Ext      string
+	Variants map[string][]byte
+}
+
+// File names the version of the image, it is what profiles store.
+func (i *Image) File() string {
+	return i.Version + "." + i.Ext
+}
+
+// Process checks the upload by its content rather than what the client
+// claims it is, and produces every variant of the kind.
+func Process(k Kind, data []byte) (*Image, error) {
+	if len(data) > k.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	format, ok := formats[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || name != format {
+		return nil, ErrCorrupt
+	}
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return nil, ErrCorrupt
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrDimensions
+	}
+	processing <- struct{}{}
+	defer func() { <-processing }()
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+
+	sum := sha256.Sum256(data)
+	img := &Image{
+		Kind:     k,
+		Version:  hex.EncodeToString(sum[:6]),
+		Ext:      "jpg",
+		Variants: make(map[string][]byte, len(k.Sizes)),
+	}
+	if !opaque(src) {
+		img.Ext = "png"
+	}
+	for _, s := range k.Sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, s.Width, s.Height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop(src.Bounds(), s), draw.Src, nil)
+
+		buf := new(bytes.Buffer)
+		if img.Ext == "png" {
+			err = png.Encode(buf, dst)
+		} else {
+			err = jpeg.Encode(buf, dst, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, err
+		}
+		img.Variants[s.Name] = buf.Bytes()
+	}
+	return img, nil
+}
+
+// crop returns the largest centred part of b with the aspect ratio of s.
+func crop(b image.Rectangle, s Size) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if w*s.Height > h*s.Width {
+		cw := h * s.Width / s.Height
+		x := b.Min.X + (w-cw)/2
+		return image.Rect(x, b.Min.Y, x+cw, b.Max.Y)
+	}
+	ch := w * s.Height / s.Width
+	y := b.Min.Y + (h-ch)/2
+	return image.Rect(b.Min.X, y, b.Max.X, y+ch)
+}
+
+func opaque(m image.Image) bool {
+	if o, ok := m.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+func key(k Kind, profile uint64, file, size string) string {
+	dot := strings.LastIndexByte(file, '.')
+	return fmt.Sprintf("%ss/%d/%s-%s%s", k.Name, profile, file[:dot], size, file[dot:])
+}
+
+// Save stores every variant of the image as the profile's, a partially
+// stored image is removed again.
+func Save(profile uint64, img *Image) error {
+	ct := "image/jpeg"
+	if img.Ext == "png" {
+		ct = "image/png"
+	}
+	for _, s := range img.Kind.Sizes {
+		if err := Store.Put(key(img.Kind, profile, img.File(), s.Name), img.Variants[s.Name], ct); err != nil {
+			Remove(img.Kind, profile, img.File())
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove deletes every variant of a version of the profile's image.
+func Remove(k Kind, profile uint64, file string) error {
+	for _, s := range k.Sizes {
+		if err := Store.Delete(key(k, profile, file, s.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge deletes every image the profile ever stored.
+func Purge(profile uint64) error {
+	for _, k := range []Kind{Avatar, Banner} {
+		if err := Store.DeleteAll(fmt.Sprintf("%ss/%d", k.Name, profile)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URLs returns where each variant of a stored image is served, or nil if
+// the profile has none.
+func URLs(k Kind, profile uint64, file string) map[string]string {
+	if file == "" || !strings.Contains(file, ".") {
+		return nil
+	}
+	urls := make(map[string]string, len(k.Sizes))
+	for _, s := range k.Sizes {
+		urls[s.Name] = Store.URL(key(k, profile, file, s.Name))
+	}
+	return urls
+}
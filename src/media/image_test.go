@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"testing"
+)
+
+// chunk encodes a PNG chunk of the given type.
+func chunk(typ string, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(typ)
+	buf.Write(data)
+	binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+	return buf.Bytes()
+}
+
+func picture(w, h int, alpha uint8) *image.NRGBA {
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.Set(x, y, color.NRGBA{uint8(x), uint8(y), 128, alpha})
+		}
+	}
+	return m
+}
+
+func encodePNG(t *testing.T, m image.Image) []byte {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, m image.Image) []byte {
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, m, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// checkVariants decodes every variant and compares it to the kind's sizes.
+func checkVariants(t *testing.T, img *Image, format string) {
+	if len(img.Variants) != len(img.Kind.Sizes) {
+		t.Fatalf("got %d variants, want %d", len(img.Variants), len(img.Kind.Sizes))
+	}
+	for _, s := range img.Kind.Sizes {
+		cfg, name, err := image.DecodeConfig(bytes.NewReader(img.Variants[s.Name]))
+		if err != nil {
+			t.Fatalf("%s: %v", s.Name, err)
+		}
+		if name != format {
+			t.Errorf("%s: encoded as %s, want %s", s.Name, name, format)
+		}
+		if cfg.Width != s.Width || cfg.Height != s.Height {
+			t.Errorf("%s: got %dx%d, want %dx%d", s.Name, cfg.Width, cfg.Height, s.Width, s.Height)
+		}
+	}
+}
+
+func TestProcessStripsEXIF(t *testing.T) {
+	const marker = "Exif\x00\x00secret-gps-location"
+	for _, alpha := range []uint8{255, 100} {
+		data := encodePNG(t, picture(300, 200, alpha))
+		// the IHDR chunk ends 33 bytes in, after the signature
+		data = append(data[:33:33], append(chunk("eXIf", []byte(marker)), data[33:]...)...)
+
+		img, err := Process(Avatar, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, format := "jpg", "jpeg"
+		if alpha != 255 {
+			want, format = "png", "png"
+		}
+		if img.Ext != want {
+			t.Errorf("got extension %s, want %s", img.Ext, want)
+		}
+		checkVariants(t, img, format)
+		for name, v := range img.Variants {
+			if bytes.Contains(v, []byte("secret-gps-location")) || bytes.Contains(v, []byte("eXIf")) {
+				t.Errorf("%s: metadata survived processing", name)
+			}
+		}
+	}
+}
+
+func TestProcessWebP(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/blue-purple-pink.lossy.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := Process(Banner, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Ext != "jpg" {
+		t.Errorf("got extension %s, want jpg", img.Ext)
+	}
+	checkVariants(t, img, "jpeg")
+}
+
+func TestProcessSniffsContent(t *testing.T) {
+	// A JPEG uploaded as avatar.png is processed as the JPEG it is.
+	jpg := encodeJPEG(t, picture(100, 100, 255))
+	img, err := Process(Avatar, jpg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkVariants(t, img, "jpeg")
+
+	// A PNG signature in front of anything else doesn't decode.
+	fake := append([]byte("\x89PNG\r\n\x1a\n"), jpg...)
+	if _, err := Process(Avatar, fake); err != ErrCorrupt {
+		t.Errorf("got %v for a JPEG behind a PNG signature, want %v", err, ErrCorrupt)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gif.Encode(buf, picture(10, 10, 255), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Process(Avatar, buf.Bytes()); err != ErrUnsupported {
+		t.Errorf("got %v for a GIF, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestProcessOversized(t *testing.T) {
+	data := make([]byte, Avatar.MaxBytes+1)
+	copy(data, encodePNG(t, picture(10, 10, 255)))
+	if _, err := Process(Avatar, data); err != ErrTooLarge {
+		t.Errorf("got %v for %d bytes, want %v", err, len(data), ErrTooLarge)
+	}
+
+	// Only the header is needed to claim 5000x4000 pixels.
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 5000)
+	binary.BigEndian.PutUint32(ihdr[4:], 4000)
+	ihdr[8], ihdr[9] = 8, 2
+	bomb := []byte("\x89PNG\r\n\x1a\n")
+	bomb = append(bomb, chunk("IHDR", ihdr)...)
+	bomb = append(bomb, chunk("IEND", nil)...)
+	if _, err := Process(Avatar, bomb); err != ErrDimensions {
+		t.Errorf("got %v for 5000x4000 pixels, want %v", err, ErrDimensions)
+	}
+}
+
+func TestCrop(t *testing.T) {
+	for _, tc := range []struct {
+		b    image.Rectangle
+		s    Size
+		want image.Rectangle
+	}{
+		{image.Rect(0, 0, 300, 200), Size{"", 64, 64}, image.Rect(50, 0, 250, 200)},
+		{image.Rect(0, 0, 200, 300), Size{"", 64, 64}, image.Rect(0, 50, 200, 250)},
+		{image.Rect(0, 0, 300, 300), Size{"", 1500, 500}, image.Rect(0, 100, 300, 200)},
+		{image.Rect(10, 10, 110, 60), Size{"", 50, 25}, image.Rect(10, 10, 110, 60)},
+	} {
+		if got := crop(tc.b, tc.s); got != tc.want {
+			t.Errorf("crop(%v, %dx%d) = %v, want %v", tc.b, tc.s.Width, tc.s.Height, got, tc.want)
+		}
+	}
+}
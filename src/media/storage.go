@@ -0,0 +1,101 @@
+package media
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spidernest-go/logger"
+)
+
+const DriverLocal = "local"
+
+// Storage keeps processed images under slash separated keys and hands out
+// the public URL they are served from.
+type Storage interface {
+	Put(key string, data []byte, contentType string) error
+	Delete(key string) error
+	// DeleteAll removes every key below prefix.
+	DeleteAll(prefix string) error
+	URL(key string) string
+}
+
+// Store is the Storage every package goes through, it is selected by
+// Configure from MEDIA_DRIVER unless it has already been set.
+var Store Storage
+
+// Driver returns the storage driver selected by the environment.
+func Driver() string {
+	switch d := os.Getenv("MEDIA_DRIVER"); d {
+	case "":
+		return DriverLocal
+	default:
+		return d
+	}
+}
+
+func Configure() {
+	if Store != nil {
+		return
+	}
+
+	base := os.Getenv("MEDIA_URL")
+	if base == "" {
+		public := os.Getenv("PUBLIC_URL")
+		if public == "" {
+			public = "http://localhost:5000"
+		}
+		base = strings.TrimRight(public, "/") + "/media"
+	}
+
+	switch Driver() {
+	case DriverLocal:
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "profiles-media")
+		}
+		Store = &LocalStorage{Dir: dir, BaseURL: strings.TrimRight(base, "/")}
+	default:
+		logger.Fatal().
+			Msgf("Media driver (%s) is not supported.", Driver())
+	}
+
+	logger.Info().
+		Msgf("Media is stored through the %s driver.", Driver())
+}
+
+// LocalStorage writes every key to a file below Dir, the service serves
+// them itself under BaseURL.
+type LocalStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func (l *LocalStorage) path(key string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (l *LocalStorage) Put(key string, data []byte, contentType string) error {
+	p := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, data, 0644)
+}
+
+func (l *LocalStorage) Delete(key string) error {
+	if err := os.Remove(l.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) DeleteAll(prefix string) error {
+	return os.RemoveAll(l.path(prefix))
+}
+
+func (l *LocalStorage) URL(key string) string {
+	return l.BaseURL + "/" + key
+}
@@ -0,0 +1,95 @@
+package media
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempStorage(t *testing.T) *LocalStorage {
+	dir, err := ioutil.TempDir("", "profiles-media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &LocalStorage{Dir: dir, BaseURL: "http://localhost:5000/media"}
+}
+
+func TestLocalStorage(t *testing.T) {
+	l := tempStorage(t)
+	defer os.RemoveAll(l.Dir)
+
+	data := []byte("variant")
+	if err := l.Put("avatars/1/abc-large.png", data, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(l.Dir, "avatars", "1", "abc-large.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("read back %q, want %q", got, data)
+	}
+	if u := l.URL("avatars/1/abc-large.png"); u != "http://localhost:5000/media/avatars/1/abc-large.png" {
+		t.Errorf("got URL %s", u)
+	}
+
+	// keys can't escape the directory
+	if err := l.Put("../../escape.png", data, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(l.Dir, "escape.png")); err != nil {
+		t.Errorf("escaping key was not kept below Dir: %v", err)
+	}
+
+	if err := l.Delete("avatars/1/abc-large.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete("avatars/1/abc-large.png"); err != nil {
+		t.Errorf("deleting a missing key: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(l.Dir, "avatars", "1", "abc-large.png")); !os.IsNotExist(err) {
+		t.Errorf("key still exists after Delete: %v", err)
+	}
+}
+
+func TestSaveAndPurge(t *testing.T) {
+	l := tempStorage(t)
+	defer os.RemoveAll(l.Dir)
+	prev := Store
+	Store = l
+	defer func() { Store = prev }()
+
+	img, err := Process(Avatar, encodePNG(t, picture(100, 100, 255)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(7, img); err != nil {
+		t.Fatal(err)
+	}
+	urls := URLs(Avatar, 7, img.File())
+	for _, s := range Avatar.Sizes {
+		want := "http://localhost:5000/media/avatars/7/" + img.Version + "-" + s.Name + ".jpg"
+		if urls[s.Name] != want {
+			t.Errorf("%s: got URL %s, want %s", s.Name, urls[s.Name], want)
+		}
+		got, err := ioutil.ReadFile(filepath.Join(l.Dir, "avatars", "7", img.Version+"-"+s.Name+".jpg"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, img.Variants[s.Name]) {
+			t.Errorf("%s: stored variant differs", s.Name)
+		}
+	}
+	if URLs(Avatar, 7, "") != nil {
+		t.Error("got URLs for a profile without an avatar")
+	}
+
+	if err := Purge(7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(l.Dir, "avatars", "7")); !os.IsNotExist(err) {
+		t.Errorf("avatars remain after Purge: %v", err)
+	}
+}
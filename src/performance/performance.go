@@ -0,0 +1,47 @@
+package performance
+
+import (
+	"math"
+)
+
+// Version changes whenever Play or Rating would compute different values,
+// stored values computed with an older version are recomputed at startup.
+const Version = "1"
+
+const (
+	// TopPlays is how many of a profile's best plays count towards its
+	// rating, only the best play of every board is counted.
+	TopPlays = 100
+
+	// decay weighs the n-th best play by decay^n.
+	decay = 0.95
+
+	maxBonus   = 416.6667
+	bonusDecay = 0.9994
+
+	// MaxScore is the score of a flawless play.
+	MaxScore = 1000000
+)
+
+// Play is the performance value of a single play. It grows steeply with
+// difficulty and is punished hard for missing accuracy.
+func Play(score uint64, accuracy, difficulty float64) float64 {
+	s := math.Min(float64(score)/MaxScore, 1)
+	return 20 * math.Pow(difficulty, 2.2) *
+		math.Pow(accuracy/100, 6) *
+		(0.5 + 0.5*s)
+}
+
+// Rating is the decaying weighted sum of tops, sorted best first, plus a
+// bonus for the number of plays that approaches maxBonus.
+func Rating(tops []float64, playCount uint64) float64 {
+	var r, w float64 = 0, 1
+	for i, p := range tops {
+		if i == TopPlays {
+			break
+		}
+		r += p * w
+		w *= decay
+	}
+	return r + maxBonus*(1-math.Pow(bonusDecay, float64(playCount)))
+}
@@ -0,0 +1,80 @@
+package performance
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestPlay(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		score      uint64
+		accuracy   float64
+		difficulty float64
+		want       float64
+	}{
+		{"flawless at difficulty 1", MaxScore, 100, 1, 20},
+		{"no difficulty", MaxScore, 100, 0, 0},
+		{"no score", 0, 100, 1, 10},
+		{"half the score", MaxScore / 2, 100, 1, 15},
+		{"score above the max", 2 * MaxScore, 100, 1, 20},
+		{"half the accuracy", MaxScore, 50, 1, 20 * math.Pow(0.5, 6)},
+		{"no accuracy", MaxScore, 0, 1, 0},
+		{"difficulty 2", MaxScore, 100, 2, 20 * math.Pow(2, 2.2)},
+	} {
+		if got := Play(tc.score, tc.accuracy, tc.difficulty); !near(got, tc.want) {
+			t.Errorf("%s: got %f, want %f", tc.name, got, tc.want)
+		}
+	}
+
+	// missing accuracy costs more than missing score
+	if Play(MaxScore, 95, 5) >= Play(MaxScore*95/100, 100, 5) {
+		t.Error("5% accuracy costs less than 5% score")
+	}
+}
+
+func TestRating(t *testing.T) {
+	ones := func(n int) []float64 {
+		ps := make([]float64, n)
+		for i := range ps {
+			ps[i] = 1
+		}
+		return ps
+	}
+	bonus := func(plays uint64) float64 {
+		return maxBonus * (1 - math.Pow(bonusDecay, float64(plays)))
+	}
+	top := (1 - math.Pow(decay, TopPlays)) / (1 - decay)
+
+	for _, tc := range []struct {
+		name  string
+		tops  []float64
+		plays uint64
+		want  float64
+	}{
+		{"nothing played", nil, 0, 0},
+		{"one play", []float64{100}, 0, 100},
+		{"second play weighted less", []float64{100, 100}, 0, 195},
+		{"third play weighted less again", []float64{100, 100, 100}, 0, 100 + 95 + 90.25},
+		{"exactly the top plays", ones(TopPlays), 0, top},
+		{"beyond the top plays", ones(TopPlays + 50), 0, top},
+		{"bonus of one play", nil, 1, bonus(1)},
+		{"bonus of many plays", []float64{100}, 5000, 100 + bonus(5000)},
+	} {
+		if got := Rating(tc.tops, tc.plays); !near(got, tc.want) {
+			t.Errorf("%s: got %f, want %f", tc.name, got, tc.want)
+		}
+	}
+
+	// the bonus approaches but never passes its maximum
+	if b := Rating(nil, 1<<40); b > maxBonus || maxBonus-b > 1e-3 {
+		t.Errorf("bonus of endless plays is %f, want close to %f", b, maxBonus)
+	}
+	if Rating(nil, 1000) <= Rating(nil, 999) {
+		t.Error("bonus doesn't grow with every play")
+	}
+}
@@ -0,0 +1,67 @@
+package policy
+
+import (
+	"net/http"
+
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+// TokenKey is the echo context key authentication middleware must store the
+// caller's *Token under for Require to find it.
+const TokenKey = "policy.token"
+
+// OwnerFunc resolves the subject owning the resource a request targets.
+type OwnerFunc func(c echo.Context) (string, error)
+
+// Denial is the body of every 401 and 403 returned by Require.
+type Denial struct {
+	Message  string
+	Requires string
+}
+
+// Require builds a middleware rejecting callers whose token does not satisfy
+// rule. owner may be nil for routes that don't target an owned resource.
+func Require(rule Rule, owner OwnerFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			t, ok := c.Get(TokenKey).(*Token)
+			if !ok {
+				return unauthenticated(c, rule)
+			}
+
+			tkn := *t
+			if owner != nil {
+				o, err := owner(c)
+				if err != nil {
+					logger.Warn().
+						Err(err).
+						Msg("Owner of the requested resource could not be resolved.")
+				}
+				tkn.Owner = o
+			}
+
+			if !rule.Allows(&tkn) {
+				return deny(c, rule)
+			}
+			return next(c)
+		}
+	}
+}
+
+func deny(c echo.Context, rule Rule) error {
+	return c.JSON(http.StatusForbidden, &Denial{
+		Message:  "Caller is not permitted to perform this action.",
+		Requires: rule.String(),
+	})
+}
+
+// unauthenticated answers requests that reached Require without a token,
+// the caller has to authenticate before any rule can be evaluated.
+func unauthenticated(c echo.Context, rule Rule) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="profiles"`)
+	return c.JSON(http.StatusUnauthorized, &Denial{
+		Message:  "Caller is not authenticated.",
+		Requires: rule.String(),
+	})
+}
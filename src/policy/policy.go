@@ -0,0 +1,156 @@
+// Package policy evaluates per-route access requirements against the scopes,
+// groups and subject of a verified access token. It holds no state of its own
+// so any Orchestra service can declare its routes' requirements with it.
+package policy
+
+import (
+	"strings"
+)
+
+// Token is what rules are evaluated against.
+type Token struct {
+	Subject string
+	Scopes  []string
+	Groups  []string
+
+	// Owner is the subject owning the resource being accessed, left empty
+	// when the resource has no owner or doesn't exist.
+	Owner string
+}
+
+// Rule is a single access requirement.
+type Rule interface {
+	Allows(t *Token) bool
+	String() string
+}
+
+// levels orders the standard scope suffixes, a scope satisfies any
+// requirement on the same resource at or below its own level.
+var levels = map[string]int{
+	"read":  1,
+	"write": 2,
+	"admin": 3,
+}
+
+type scope string
+
+// Scope requires the token to carry name. For the standard resource:level
+// scopes a higher level also satisfies it, so "score:admin" is enough for
+// Scope("score:write") and "score:write" is enough for Scope("score:read").
+func Scope(name string) Rule {
+	return scope(name)
+}
+
+func (s scope) Allows(t *Token) bool {
+	res, lvl := split(string(s))
+	for _, have := range t.Scopes {
+		if have == string(s) {
+			return true
+		}
+		hres, hlvl := split(have)
+		if lvl > 0 && hres == res && hlvl >= lvl {
+			return true
+		}
+	}
+	return false
+}
+
+func (s scope) String() string {
+	return string(s)
+}
+
+func split(s string) (string, int) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, 0
+	}
+	return s[:i], levels[s[i+1:]]
+}
+
+type group string
+
+// Group requires the token's subject to be a member of name.
+func Group(name string) Rule {
+	return group(name)
+}
+
+func (g group) Allows(t *Token) bool {
+	for _, have := range t.Groups {
+		if have == string(g) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g group) String() string {
+	return "group " + string(g)
+}
+
+type owner struct{}
+
+// Owner requires the token's subject to own the resource being accessed.
+func Owner() Rule {
+	return owner{}
+}
+
+func (owner) Allows(t *Token) bool {
+	return t.Owner != "" && t.Subject == t.Owner
+}
+
+func (owner) String() string {
+	return "owner"
+}
+
+type anyOf []Rule
+
+// AnyOf is satisfied when at least one of rules is.
+func AnyOf(rules ...Rule) Rule {
+	return anyOf(rules)
+}
+
+func (a anyOf) Allows(t *Token) bool {
+	for _, r := range a {
+		if r.Allows(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a anyOf) String() string {
+	return join(a, " or ")
+}
+
+type allOf []Rule
+
+// AllOf is satisfied only when every one of rules is.
+func AllOf(rules ...Rule) Rule {
+	return allOf(rules)
+}
+
+func (a allOf) Allows(t *Token) bool {
+	for _, r := range a {
+		if !r.Allows(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a allOf) String() string {
+	return join(a, " and ")
+}
+
+func join(rules []Rule, sep string) string {
+	strs := make([]string, len(rules))
+	for i, r := range rules {
+		strs[i] = r.String()
+		if _, ok := r.(anyOf); ok && len(rules) > 1 {
+			strs[i] = "(" + strs[i] + ")"
+		} else if _, ok := r.(allOf); ok && len(rules) > 1 {
+			strs[i] = "(" + strs[i] + ")"
+		}
+	}
+	return strings.Join(strs, sep)
+}
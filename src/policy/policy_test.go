@@ -0,0 +1,189 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/spidernest-go/mux"
+	"github.com/valyala/fasthttp"
+)
+
+func TestScope(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		scopes []string
+		rule   string
+		want   bool
+	}{
+		{"exact", []string{"score:write"}, "score:write", true},
+		{"admin implies write", []string{"score:admin"}, "score:write", true},
+		{"admin implies read", []string{"score:admin"}, "score:read", true},
+		{"write implies read", []string{"score:write"}, "score:read", true},
+		{"read doesn't imply write", []string{"score:read"}, "score:write", false},
+		{"write doesn't imply admin", []string{"score:write"}, "score:admin", false},
+		{"other resource", []string{"invite:admin"}, "score:read", false},
+		{"resource prefix", []string{"score:admin"}, "sc:read", false},
+		{"unknown level only matches exactly", []string{"score:admin"}, "score:delete", false},
+		{"unknown level exact", []string{"score:delete"}, "score:delete", true},
+		{"no level", []string{"email"}, "email", true},
+		{"no level isn't implied", []string{"email:admin"}, "email", false},
+		{"no scopes", nil, "score:read", false},
+		{"one of several", []string{"profile", "invite:read", "score:write"}, "score:read", true},
+	} {
+		if got := Scope(tc.rule).Allows(&Token{Scopes: tc.scopes}); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tkn := &Token{Groups: []string{"staff", "mods"}}
+	if !Group("mods").Allows(tkn) {
+		t.Error("member of mods was refused")
+	}
+	if Group("admin").Allows(tkn) {
+		t.Error("non-member of admin was allowed")
+	}
+}
+
+func TestOwner(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		tkn  Token
+		want bool
+	}{
+		{"owner", Token{Subject: "a", Owner: "a"}, true},
+		{"someone else", Token{Subject: "b", Owner: "a"}, false},
+		{"no owner", Token{Subject: "a"}, false},
+		{"no subject and no owner", Token{}, false},
+	} {
+		if got := Owner().Allows(&tc.tkn); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// counted records how often it was evaluated.
+type counted struct {
+	allows bool
+	n      *int
+}
+
+func (c counted) Allows(*Token) bool {
+	*c.n++
+	return c.allows
+}
+
+func (c counted) String() string {
+	return "counted"
+}
+
+func TestAnyOfAllOf(t *testing.T) {
+	var n int
+	yes, no := counted{true, &n}, counted{false, &n}
+
+	for _, tc := range []struct {
+		name      string
+		rule      Rule
+		want      bool
+		evaluated int
+	}{
+		{"any of nothing", AnyOf(), false, 0},
+		{"any stops at the first allowing", AnyOf(no, yes, yes), true, 2},
+		{"any of refusing", AnyOf(no, no), false, 2},
+		{"all of nothing", AllOf(), true, 0},
+		{"all stops at the first refusing", AllOf(yes, no, yes), false, 2},
+		{"all of allowing", AllOf(yes, yes), true, 2},
+	} {
+		n = 0
+		if got := tc.rule.Allows(&Token{}); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		if n != tc.evaluated {
+			t.Errorf("%s: evaluated %d rules, want %d", tc.name, n, tc.evaluated)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, tc := range []struct {
+		rule Rule
+		want string
+	}{
+		{Scope("score:write"), "score:write"},
+		{AnyOf(Owner(), Scope("profile:admin")), "owner or profile:admin"},
+		{AllOf(Group("staff"), AnyOf(Owner(), Scope("profile:admin"))), "group staff and (owner or profile:admin)"},
+		{AnyOf(AllOf(Owner())), "owner"},
+	} {
+		if got := tc.rule.String(); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func serve(rule Rule, owner OwnerFunc, tkn *Token) (*fasthttp.RequestCtx, bool) {
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Init(new(fasthttp.Request), nil, nil)
+	c := echo.New().NewContext(ctx)
+	if tkn != nil {
+		c.Set(TokenKey, tkn)
+	}
+	reached := false
+	Require(rule, owner)(func(c echo.Context) error {
+		reached = true
+		return c.NoContent(http.StatusNoContent)
+	})(c)
+	return ctx, reached
+}
+
+func TestRequire(t *testing.T) {
+	isOwner := func(echo.Context) (string, error) { return "a", nil }
+	missing := func(echo.Context) (string, error) { return "", errors.New("no such profile") }
+	rule := AnyOf(Owner(), Scope("profile:admin"))
+
+	for _, tc := range []struct {
+		name  string
+		owner OwnerFunc
+		tkn   *Token
+		want  int
+	}{
+		{"no token", isOwner, nil, http.StatusUnauthorized},
+		{"owner", isOwner, &Token{Subject: "a"}, http.StatusNoContent},
+		{"someone else", isOwner, &Token{Subject: "b"}, http.StatusForbidden},
+		{"admin", isOwner, &Token{Subject: "b", Scopes: []string{"profile:admin"}}, http.StatusNoContent},
+		{"owner can't be resolved", missing, &Token{Subject: "a"}, http.StatusForbidden},
+		{"admin without an owner", missing, &Token{Subject: "a", Scopes: []string{"profile:admin"}}, http.StatusNoContent},
+		{"no owner func", nil, &Token{Subject: "a"}, http.StatusForbidden},
+	} {
+		ctx, reached := serve(rule, tc.owner, tc.tkn)
+		if got := ctx.Response.StatusCode(); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+		if reached != (tc.want == http.StatusNoContent) {
+			t.Errorf("%s: handler reached is %v", tc.name, reached)
+		}
+	}
+
+	// the token in the context isn't changed by resolving the owner
+	tkn := &Token{Subject: "a"}
+	serve(rule, isOwner, tkn)
+	if tkn.Owner != "" {
+		t.Error("owner leaked into the caller's token")
+	}
+
+	ctx, _ := serve(rule, isOwner, &Token{Subject: "b"})
+	d := new(Denial)
+	if err := json.Unmarshal(ctx.Response.Body(), d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Requires != "owner or profile:admin" {
+		t.Errorf("denial requires %q", d.Requires)
+	}
+
+	ctx, _ = serve(rule, isOwner, nil)
+	if h := string(ctx.Response.Header.Peek("WWW-Authenticate")); h != `Bearer realm="profiles"` {
+		t.Errorf("got WWW-Authenticate %q without a token", h)
+	}
+}
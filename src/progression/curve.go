@@ -0,0 +1,177 @@
+package progression
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"github.com/spidernest-go/logger"
+)
+
+// Curve derives levels from experience and decides how much experience a
+// play is worth.
+//
+// Levels either follow the formula Base * (level - 1) ^ Exponent, giving the
+// experience needed to reach a level, or Table, which lists that amount for
+// every level starting at level 1.
+type Curve struct {
+	Levels struct {
+		Base     float64  `json:"base"`
+		Exponent float64  `json:"exponent"`
+		Max      uint64   `json:"max"`
+		Table    []uint64 `json:"table,omitempty"`
+	} `json:"levels"`
+
+	// A play awards (Base + score / ScoreDivisor) * (1 + difficulty *
+	// DifficultyWeight) * (accuracy / 100) ^ AccuracyExponent experience.
+	Experience struct {
+		Base             float64 `json:"base"`
+		ScoreDivisor     float64 `json:"score_divisor"`
+		DifficultyWeight float64 `json:"difficulty_weight"`
+		AccuracyExponent float64 `json:"accuracy_exponent"`
+	} `json:"experience"`
+}
+
+// Progress is how far a profile is into its current level.
+type Progress struct {
+	Level    uint64  `json:"level"`
+	Current  uint64  `json:"current"`
+	Required uint64  `json:"required"`
+	Percent  float64 `json:"percent"`
+}
+
+// Active is the curve everything is computed with.
+var Active = Default()
+
+var ErrInvalidCurve = errors.New("Level curve is invalid.")
+
+func Default() *Curve {
+	c := new(Curve)
+	c.Levels.Base = 500
+	c.Levels.Exponent = 1.8
+	c.Levels.Max = 200
+	c.Experience.Base = 50
+	c.Experience.ScoreDivisor = 20000
+	c.Experience.DifficultyWeight = 0.25
+	c.Experience.AccuracyExponent = 2
+	return c
+}
+
+// Load replaces Active with the curve in the JSON file at LEVEL_CURVE, if it
+// is set.
+func Load() {
+	path := os.Getenv("LEVEL_CURVE")
+	if path == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.Fatal().
+			Err(err).
+			Msg("Level curve could not be read.")
+	}
+	c := Default()
+	if err := json.Unmarshal(data, c); err != nil {
+		logger.Fatal().
+			Err(err).
+			Msg("Level curve is not valid JSON.")
+	}
+	if err := c.Validate(); err != nil {
+		logger.Fatal().
+			Err(err).
+			Msg("Level curve could not be loaded.")
+	}
+	Active = c
+}
+
+// Validate checks that levels can be derived from the curve, a table has to
+// start at 0 and keep increasing.
+func (c *Curve) Validate() error {
+	if t := c.Levels.Table; len(t) > 0 {
+		if t[0] != 0 {
+			return ErrInvalidCurve
+		}
+		for i := 1; i < len(t); i++ {
+			if t[i] <= t[i-1] {
+				return ErrInvalidCurve
+			}
+		}
+	} else if c.Levels.Base <= 0 || c.Levels.Exponent <= 0 || c.Levels.Max < 1 {
+		return ErrInvalidCurve
+	}
+	if c.Experience.ScoreDivisor <= 0 || c.Experience.Base < 0 ||
+		c.Experience.DifficultyWeight < 0 || c.Experience.AccuracyExponent < 0 {
+		return ErrInvalidCurve
+	}
+	return nil
+}
+
+// Fingerprint changes whenever the curve would derive different levels.
+func (c *Curve) Fingerprint() string {
+	data, _ := json.Marshal(c.Levels)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// MaxLevel is the highest level that can be reached.
+func (c *Curve) MaxLevel() uint64 {
+	if t := c.Levels.Table; len(t) > 0 {
+		return uint64(len(t))
+	}
+	return c.Levels.Max
+}
+
+// Threshold is the experience needed to reach level.
+func (c *Curve) Threshold(level uint64) uint64 {
+	if level <= 1 {
+		return 0
+	}
+	if level > c.MaxLevel() {
+		level = c.MaxLevel()
+	}
+	if t := c.Levels.Table; len(t) > 0 {
+		return t[level-1]
+	}
+	return uint64(math.Ceil(c.Levels.Base * math.Pow(float64(level-1), c.Levels.Exponent)))
+}
+
+// Level is the level reached with xp experience.
+func (c *Curve) Level(xp uint64) uint64 {
+	// thresholds only increase, so search for the last one reached
+	lo, hi := uint64(1), c.MaxLevel()
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		if c.Threshold(mid) <= xp {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+func (c *Curve) Progress(xp uint64) *Progress {
+	l := c.Level(xp)
+	p := &Progress{Level: l, Current: xp - c.Threshold(l)}
+	if l >= c.MaxLevel() {
+		p.Percent = 100
+		return p
+	}
+	p.Required = c.Threshold(l+1) - c.Threshold(l)
+	p.Percent = math.Floor(float64(p.Current)/float64(p.Required)*10000) / 100
+	return p
+}
+
+// Award is the experience a play is worth.
+func (c *Curve) Award(score uint64, accuracy, difficulty float64) uint64 {
+	e := c.Experience
+	xp := (e.Base + float64(score)/e.ScoreDivisor) *
+		(1 + difficulty*e.DifficultyWeight) *
+		math.Pow(accuracy/100, e.AccuracyExponent)
+	return uint64(math.Round(xp))
+}
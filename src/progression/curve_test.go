@@ -0,0 +1,138 @@
+package progression
+
+import (
+	"testing"
+)
+
+func tableCurve(t ...uint64) *Curve {
+	c := Default()
+	c.Levels.Table = t
+	return c
+}
+
+func TestThreshold(t *testing.T) {
+	c := Default()
+	table := tableCurve(0, 100, 250, 500)
+
+	for _, tc := range []struct {
+		name  string
+		c     *Curve
+		level uint64
+		want  uint64
+	}{
+		{"level 0", c, 0, 0},
+		{"level 1", c, 1, 0},
+		{"level 2", c, 2, 500},
+		{"level 3", c, 3, 1742},
+		{"beyond the max", c, 500, c.Threshold(200)},
+		{"table level 0", table, 0, 0},
+		{"table level 1", table, 1, 0},
+		{"table level 3", table, 3, 250},
+		{"table max", table, 4, 500},
+		{"table beyond the max", table, 5, 500},
+	} {
+		if got := tc.c.Threshold(tc.level); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	c := Default()
+	table := tableCurve(0, 100, 250, 500)
+
+	for _, tc := range []struct {
+		name string
+		c    *Curve
+		xp   uint64
+		want uint64
+	}{
+		{"no experience", c, 0, 1},
+		{"just below level 2", c, 499, 1},
+		{"exactly level 2", c, 500, 2},
+		{"just below level 3", c, 1741, 2},
+		{"exactly level 3", c, 1742, 3},
+		{"exactly the max", c, c.Threshold(200), 200},
+		{"far beyond the max", c, 1 << 62, 200},
+		{"table no experience", table, 0, 1},
+		{"table exactly level 2", table, 100, 2},
+		{"table between levels", table, 249, 2},
+		{"table exactly the max", table, 500, 4},
+		{"table beyond the max", table, 10000, 4},
+	} {
+		if got := tc.c.Level(tc.xp); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	table := tableCurve(0, 100, 250, 500)
+
+	for _, tc := range []struct {
+		name string
+		xp   uint64
+		want Progress
+	}{
+		{"start", 0, Progress{Level: 1, Current: 0, Required: 100, Percent: 0}},
+		{"partway", 175, Progress{Level: 2, Current: 75, Required: 150, Percent: 50}},
+		{"a third", 150, Progress{Level: 2, Current: 50, Required: 150, Percent: 33.33}},
+		{"exactly at a threshold", 250, Progress{Level: 3, Current: 0, Required: 250, Percent: 0}},
+		{"max level", 500, Progress{Level: 4, Current: 0, Percent: 100}},
+		{"past the max level", 800, Progress{Level: 4, Current: 300, Percent: 100}},
+	} {
+		if got := table.Progress(tc.xp); *got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, *got, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("default curve: %v", err)
+	}
+	if err := tableCurve(0, 100, 250).Validate(); err != nil {
+		t.Errorf("increasing table: %v", err)
+	}
+
+	for name, c := range map[string]*Curve{
+		"table not starting at 0": tableCurve(10, 100),
+		"table not increasing":    tableCurve(0, 100, 100),
+		"table decreasing":        tableCurve(0, 100, 50),
+	} {
+		if err := c.Validate(); err != ErrInvalidCurve {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalidCurve)
+		}
+	}
+	for name, change := range map[string]func(c *Curve){
+		"no base":             func(c *Curve) { c.Levels.Base = 0 },
+		"no exponent":         func(c *Curve) { c.Levels.Exponent = 0 },
+		"no max":              func(c *Curve) { c.Levels.Max = 0 },
+		"no score divisor":    func(c *Curve) { c.Experience.ScoreDivisor = 0 },
+		"negative base xp":    func(c *Curve) { c.Experience.Base = -1 },
+		"negative difficulty": func(c *Curve) { c.Experience.DifficultyWeight = -1 },
+		"negative accuracy":   func(c *Curve) { c.Experience.AccuracyExponent = -1 },
+	} {
+		c := Default()
+		change(c)
+		if err := c.Validate(); err != ErrInvalidCurve {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalidCurve)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := Default(), Default()
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("equal curves have different fingerprints")
+	}
+	// experience doesn't change any level
+	b.Experience.Base++
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("experience changed the fingerprint")
+	}
+	b.Levels.Max++
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("a different max level has the same fingerprint")
+	}
+}
@@ -0,0 +1,66 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/achievements"
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/mux"
+)
+
+// achievement is a catalog entry along with when the profile unlocked it.
+type achievement struct {
+	achievements.Achievement
+	Unlocked *time.Time `json:"unlocked"`
+}
+
+func getAchievements(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if _, err := viewable(c, id); err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	us, err := database.SelectUnlocks(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	unlocked := make(map[string]time.Time, len(us))
+	for _, u := range us {
+		unlocked[u.Achievement] = u.Unlocked
+	}
+
+	// hidden achievements stay secret until they are unlocked, unlocks of
+	// achievements since dropped from the catalog aren't listed
+	as := []achievement{}
+	n := 0
+	for _, a := range achievements.Active {
+		t, ok := unlocked[a.ID]
+		if !ok && a.Hidden {
+			continue
+		}
+		e := achievement{Achievement: a}
+		if ok {
+			e.Unlocked = &t
+			n++
+		}
+		as = append(as, e)
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Unlocked     int           `json:"unlocked"`
+		Total        int           `json:"total"`
+		Achievements []achievement `json:"achievements"`
+	}{
+		Unlocked:     n,
+		Total:        len(achievements.Active),
+		Achievements: as,
+	})
+}
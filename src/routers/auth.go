@@ -0,0 +1,80 @@
+package routers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/policy"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+// CallerKey is the echo context key the verified claims of the caller are
+// stored under by authenticate.
+const CallerKey = "caller"
+
+// authenticate rejects requests without a valid bearer access token and
+// attaches the caller's claims to the request context, along with the
+// policy.Token route requirements are evaluated against.
+func authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		auth := string(c.Request().Request.Header.Peek(echo.HeaderAuthorization))
+		if len(auth) <= len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+			return unauthorized(c, "Bearer token is missing.")
+		}
+
+		claims, err := identity.VerifyAccessToken(context.Background(), auth[len("Bearer "):])
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Msg("Bearer token failed verification.")
+
+			return unauthorized(c, "Bearer token is invalid or expired.")
+		}
+
+		c.Set(CallerKey, claims)
+		c.Set(policy.TokenKey, &policy.Token{
+			Subject: claims.Subject,
+			Scopes:  strings.Fields(claims.Scope),
+			Groups:  claims.Groups,
+		})
+		return next(c)
+	}
+}
+
+// caller returns the claims authenticate attached, or nil on routes that
+// aren't authenticated.
+func caller(c echo.Context) *identity.Claims {
+	claims, _ := c.Get(CallerKey).(*identity.Claims)
+	return claims
+}
+
+func unauthorized(c echo.Context, msg string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="profiles"`)
+	return c.JSON(http.StatusUnauthorized, &struct {
+		Message string
+	}{
+		Message: msg})
+}
+
+// profileOwner resolves the subject owning the profile in the :id parameter.
+// Once a deletion removed the profile row its tombstone still knows the
+// owner, so they can resume the deletion.
+func profileOwner(c echo.Context) (string, error) {
+	i, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return "", err
+	}
+	err, pf := database.SelectProfileById(i)
+	if err == nil {
+		return pf.UUID, nil
+	}
+	if err, t := database.SelectTombstone(i); err == nil && t.UUID != "" {
+		return t.UUID, nil
+	}
+	return "", err
+}
@@ -0,0 +1,110 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/mux"
+)
+
+// viewer returns the id of the caller's profile, or 0 if it has none.
+func viewer(c echo.Context) uint64 {
+	claims := caller(c)
+	if claims == nil {
+		return 0
+	}
+	err, pf := database.SelectProfileByUUID(claims.Subject)
+	if err != nil {
+		return 0
+	}
+	return pf.ID
+}
+
+// blockedBy reports whether the profile blocked the caller, lookups that
+// fail count as blocked.
+func blockedBy(c echo.Context, profile uint64) bool {
+	v := viewer(c)
+	if v == 0 || v == profile {
+		return false
+	}
+	blocked, err := database.Blocks(profile, v)
+	return err != nil || blocked
+}
+
+// viewable looks up the profile, answering as if it didn't exist when it
+// blocked the caller.
+func viewable(c echo.Context, id uint64) (*database.Profile, error) {
+	err, pf := database.SelectProfileById(id)
+	if err != nil {
+		return nil, err
+	}
+	if blockedBy(c, id) {
+		return nil, database.ErrProfileNotFound
+	}
+	return pf, nil
+}
+
+func blockProfile(c echo.Context) error {
+	pf, id, err := counterpart(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	switch err := database.Block(pf.ID, id); err {
+	case nil:
+		return c.NoContent(http.StatusNoContent)
+	case database.ErrSelfBlock:
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+}
+
+func unblockProfile(c echo.Context) error {
+	pf, id, err := counterpart(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	if err := database.Unblock(pf.ID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func listBlocked(c echo.Context) error {
+	err, pf := database.SelectProfileByUUID(caller(c).Subject)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	page, perPage := pagination(c)
+
+	cs, total, err := database.SelectBlocked(pf.ID, page, perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Profiles []database.Connection `json:"profiles"`
+		Page     uint                  `json:"page"`
+		PerPage  uint                  `json:"per_page"`
+		Total    uint64                `json:"total"`
+	}{
+		Profiles: cs,
+		Page:     page,
+		PerPage:  perPage,
+		Total:    total,
+	})
+}
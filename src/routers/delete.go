@@ -0,0 +1,54 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/jobs"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+func deleteProfile(c echo.Context) error {
+	i, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Passed id parameter (%s) was not a valid number", c.Param("id"))
+
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	// A deletion that failed midway is resumed rather than started over
+	err, t := database.SelectTombstone(i)
+	if err != nil {
+		err, pf := database.SelectProfileById(i)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, ErrGeneric)
+		}
+
+		t, err = pf.Bury()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, &struct {
+				Message string
+			}{
+				Message: "Database could not be reached."})
+		}
+	}
+	if t.Done() {
+		return c.JSON(http.StatusGone, &struct {
+			Message string
+		}{
+			Message: "Profile was already deleted."})
+	}
+
+	if err := jobs.DeleteAccount(t); err != nil {
+		return c.JSON(http.StatusAccepted, &struct {
+			Message string
+		}{
+			Message: "Profile deletion started and will be completed shortly."})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
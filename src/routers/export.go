@@ -0,0 +1,82 @@
+package routers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/jobs"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+func requestExport(c echo.Context) error {
+	err, pf := database.SelectProfileByUUID(caller(c).Subject)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	if err, _ := database.SelectActiveExport(pf.ID); err == nil {
+		return c.JSON(http.StatusConflict, &struct {
+			Message string
+		}{
+			Message: "An export is already being built or waiting to be downloaded."})
+	}
+
+	token, e, err := jobs.StartExport(pf)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Export of profile %d could not be started.", pf.ID)
+
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusAccepted, &struct {
+		Status   string `json:"status"`
+		Download string `json:"download"`
+		Expires  string `json:"expires"`
+	}{
+		Status:   e.Status,
+		Download: "/api/v0/export/" + token,
+		Expires:  e.Expires.UTC().Format(time.RFC3339),
+	})
+}
+
+func downloadExport(c echo.Context) error {
+	err, e := database.SelectExportByToken(c.Param("token"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	switch {
+	case e.Status == database.ExportPending && !e.Stale():
+		return c.JSON(http.StatusAccepted, &struct {
+			Status string `json:"status"`
+		}{
+			Status: e.Status})
+	case e.Status != database.ExportReady || !e.Expires.After(time.Now()):
+		return c.JSON(http.StatusGone, &struct {
+			Message string
+		}{
+			Message: "Export was already downloaded, failed or expired."})
+	}
+
+	data, err := jobs.ReadExport(e)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Export %d could not be read.", e.ID)
+
+		return c.JSON(http.StatusGone, &struct {
+			Message string
+		}{
+			Message: "Export was already downloaded, failed or expired."})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="orchestra-personal-data.zip"`)
+	return c.Blob(http.StatusOK, "application/zip", data)
+}
@@ -0,0 +1,128 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/mux"
+)
+
+// counterpart resolves the caller's profile and the profile in the path.
+func counterpart(c echo.Context) (*database.Profile, uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err, _ := database.SelectProfileById(id); err != nil {
+		return nil, 0, err
+	}
+	err, pf := database.SelectProfileByUUID(caller(c).Subject)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pf, id, nil
+}
+
+func followProfile(c echo.Context) error {
+	pf, id, err := counterpart(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	switch err := database.Follow(pf.ID, id); err {
+	case nil:
+	case database.ErrSelfFollow:
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	case database.ErrBlocked:
+		// profiles that blocked the caller look like they don't exist
+		if mine, _ := database.Blocks(pf.ID, id); !mine {
+			return c.JSON(http.StatusNotFound, ErrGeneric)
+		}
+		return c.JSON(http.StatusConflict, &struct {
+			Message string
+		}{
+			Message: "Unblock the profile before following it."})
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	back, err := database.Follows(id, pf.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	return c.JSON(http.StatusOK, &struct {
+		Following bool `json:"following"`
+		Friends   bool `json:"friends"`
+	}{
+		Following: true,
+		Friends:   back,
+	})
+}
+
+func unfollowProfile(c echo.Context) error {
+	pf, id, err := counterpart(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	if err := database.Unfollow(pf.ID, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func listFollowers(c echo.Context) error {
+	return listConnections(c, database.SelectFollowers)
+}
+
+func listFollowing(c echo.Context) error {
+	return listConnections(c, database.SelectFollowing)
+}
+
+func listFriends(c echo.Context) error {
+	return listConnections(c, database.SelectFriends)
+}
+
+func listConnections(c echo.Context, sel func(uint64, uint64, uint, uint) ([]database.Connection, uint64, error)) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if _, err := viewable(c, id); err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	page, perPage := pagination(c)
+
+	cs, total, err := sel(id, viewer(c), page, perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Profiles []database.Connection `json:"profiles"`
+		Page     uint                  `json:"page"`
+		PerPage  uint                  `json:"per_page"`
+		Total    uint64                `json:"total"`
+	}{
+		Profiles: cs,
+		Page:     page,
+		PerPage:  perPage,
+		Total:    total,
+	})
+}
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	oidc "github.com/coreos/go-oidc"
 	"github.com/orchestrafm/profiles/src/database"
 	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/media"
+	"github.com/orchestrafm/profiles/src/progression"
 	"github.com/spidernest-go/logger"
 	"github.com/spidernest-go/mux"
 	"golang.org/x/oauth2"
@@ -25,13 +28,98 @@ func getProfileById(c echo.Context) error {
 	}
 
 	// Get profile information
-	err, pf := database.SelectProfileById(i)
+	pf, err := viewable(c, i)
 	if err != nil {
 		logger.Error().
 			Err(err).
 			Msg("Profile specified either does not exist or requesting user is unauthorized.")
 
-		c.JSON(http.StatusNotFound, ErrGeneric)
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	return showProfile(c, pf)
+}
+
+func getProfileByUUID(c echo.Context) error {
+	err, pf := database.SelectProfileByUUID(c.Param("uuid"))
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profile with UUID (%s) does not exist.", c.Param("uuid"))
+
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if blockedBy(c, pf.ID) {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	return showProfile(c, pf)
+}
+
+func getProfileByName(c echo.Context) error {
+	name := c.Param("username")
+	err, pf := database.SelectProfileByUsername(name)
+	if err != nil {
+		// profiles made before usernames were stored only know their uuid
+		if acc, aerr := identity.FindAccount(name); aerr == nil {
+			err, pf = database.SelectProfileByUUID(acc.ID)
+		} else {
+			// links to renamed profiles keep working
+			err, pf = database.SelectProfileByPreviousName(name)
+		}
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Profile with username (%s) does not exist.", name)
+
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if blockedBy(c, pf.ID) {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	return showProfile(c, pf)
+}
+
+func getMe(c echo.Context) error {
+	err, pf := database.SelectProfileByUUID(caller(c).Subject)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Caller (%s) does not have a profile.", caller(c).Subject)
+
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	return showProfile(c, pf)
+}
+
+// showProfile fills in the parts of a profile kept by the identity provider
+// and writes it out.
+func showProfile(c echo.Context, pf *database.Profile) error {
+	// statistics of a single mode come along, by default the profile's own
+	mode := strings.ToLower(c.QueryParam("mode"))
+	if mode == "" {
+		mode = pf.DefaultMode
+	}
+	if mode == "" {
+		mode = database.DefaultMode
+	}
+	if !database.ValidMode(mode) {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "mode must be 4k, 5k or 7k."})
+	}
+	ms, err := database.SelectModeStats(pf.ID, mode)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrGeneric)
+	}
+	ms.Progress = progression.Active.Progress(ms.Experience)
+	pf.ModeStats = ms
+	if pf.Followers, pf.Following, err = database.FollowCounts(pf.ID, viewer(c)); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrGeneric)
 	}
 
 	grps, err := identity.GetGroups(pf.UUID)
@@ -40,7 +128,7 @@ func getProfileById(c echo.Context) error {
 			Err(err).
 			Msg("Groups this user is in could not be retreived.")
 
-		c.JSON(http.StatusInternalServerError, ErrGeneric)
+		return c.JSON(http.StatusInternalServerError, ErrGeneric)
 	}
 	for _, grp := range grps {
 		pf.Groups = append(pf.Groups, grp.Name)
@@ -56,8 +144,19 @@ func getProfileById(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, nil)
 	}
 	pf.Username = acc.FirstName
+	if pf.DisplayName != "" {
+		pf.Username = pf.DisplayName
+	}
+	if !strings.EqualFold(pf.Handle, acc.Username) {
+		// failing to cache the username only slows down later lookups
+		pf.SetHandle(acc.Username)
+	}
+	pf.Progress = progression.Active.Progress(pf.Experience)
+	pf.Avatar = media.URLs(media.Avatar, pf.ID, pf.AvatarFile)
+	pf.Banner = media.URLs(media.Banner, pf.ID, pf.BannerFile)
 	pf.UUID = ""
 
+	c.Response().Header().Set("ETag", `"`+strconv.FormatUint(pf.Version, 10)+`"`)
 	return c.JSON(http.StatusOK, &pf)
 }
 
@@ -0,0 +1,66 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/mux"
+)
+
+// defaultHistory is how far back history goes when from is left out.
+const defaultHistory = 90 * 24 * time.Hour
+
+func getHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	to := time.Now()
+	if q := c.QueryParam("to"); q != "" {
+		if to, err = time.Parse("2006-01-02", q); err != nil {
+			return c.JSON(http.StatusBadRequest, &struct {
+				Message string
+			}{
+				Message: "to must be a date like 2006-01-02."})
+		}
+	}
+	from := to.Add(-defaultHistory)
+	if q := c.QueryParam("from"); q != "" {
+		if from, err = time.Parse("2006-01-02", q); err != nil {
+			return c.JSON(http.StatusBadRequest, &struct {
+				Message string
+			}{
+				Message: "from must be a date like 2006-01-02."})
+		}
+	}
+	if from.After(to) {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "from must not be after to."})
+	}
+
+	if _, err := viewable(c, id); err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	ss, err := database.SelectHistory(id, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		From    string              `json:"from"`
+		To      string              `json:"to"`
+		History []database.Snapshot `json:"history"`
+	}{
+		From:    database.Day(from).Format("2006-01-02"),
+		To:      database.Day(to).Format("2006-01-02"),
+		History: ss,
+	})
+}
@@ -0,0 +1,92 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/media"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+func putAvatar(c echo.Context) error {
+	return putImage(c, media.Avatar)
+}
+
+func putBanner(c echo.Context) error {
+	return putImage(c, media.Banner)
+}
+
+// putImage replaces the profile's image of kind k with the request body.
+func putImage(c echo.Context, k media.Kind) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	err, pf := database.SelectProfileById(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	img, err := media.Process(k, c.Request().PostBody())
+	switch err {
+	case nil:
+	case media.ErrTooLarge:
+		return c.JSON(http.StatusRequestEntityTooLarge, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	case media.ErrUnsupported:
+		return c.JSON(http.StatusUnsupportedMediaType, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	case media.ErrDimensions, media.ErrCorrupt:
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	default:
+		logger.Error().
+			Err(err).
+			Msgf("Image (%s) of profile %d could not be processed.", k.Name, id)
+
+		return c.JSON(http.StatusInternalServerError, ErrGeneric)
+	}
+
+	if err := media.Save(pf.ID, img); err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Image (%s) of profile %d could not be stored.", k.Name, id)
+
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Image could not be stored."})
+	}
+
+	old := pf.AvatarFile
+	if k.Name == media.Banner.Name {
+		old = pf.BannerFile
+	}
+	if err := pf.SetImage(k.Name, img.File()); err != nil {
+		if img.File() != old {
+			media.Remove(k, pf.ID, img.File())
+		}
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	if old != "" && old != img.File() {
+		// a leftover old version only costs space
+		if err := media.Remove(k, pf.ID, old); err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Old image (%s) of profile %d could not be removed.", k.Name, id)
+		}
+	}
+
+	return showProfile(c, pf)
+}
@@ -0,0 +1,167 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+const (
+	maxInviteBatch  = 100
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func mintInvites(c echo.Context) error {
+	req := new(struct {
+		Count   int        `json:"count"`
+		MaxUses uint64     `json:"max_uses"`
+		Expires *time.Time `json:"expires"`
+	})
+	if err := c.Bind(req); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invalid or malformed invite request.")
+
+		return c.JSON(http.StatusNotAcceptable, &struct {
+			Message string
+		}{
+			Message: "Invite request was invalid or malformed."})
+	}
+	errs := make(map[string]string)
+	if req.Count < 1 || req.Count > maxInviteBatch {
+		errs["count"] = "Between 1 and 100 invite codes can be minted at once."
+	}
+	if req.Expires != nil && !req.Expires.After(time.Now()) {
+		errs["expires"] = "Expiry must be in the future."
+	}
+	if len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+			Errors  map[string]string
+		}{
+			Message: "One or more fields are invalid.",
+			Errors:  errs})
+	}
+
+	// Attribute the codes to the admin's profile, if they have one
+	tmpl := database.Invite{MaxUses: req.MaxUses, Expires: req.Expires}
+	if err, pf := database.SelectProfileByUUID(caller(c).Subject); err == nil {
+		tmpl.IssuerID = &pf.ID
+	}
+
+	invs, err := database.NewInvites(req.Count, tmpl, func() (string, error) {
+		return identity.GetSecureRandomString(database.InviteCodeLength, identity.InviteAlphabet)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusCreated, &struct {
+		Invites []database.Invite `json:"invites"`
+	}{
+		Invites: invs,
+	})
+}
+
+func listInvites(c echo.Context) error {
+	var burned *bool
+	if q := c.QueryParam("burned"); q != "" {
+		b, err := strconv.ParseBool(q)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &struct {
+				Message string
+			}{
+				Message: "burned must be true or false."})
+		}
+		burned = &b
+	}
+	page, perPage := pagination(c)
+
+	invs, total, err := database.ListInvites(burned, page, perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Invites []database.Invite `json:"invites"`
+		Page    uint              `json:"page"`
+		PerPage uint              `json:"per_page"`
+		Total   uint64            `json:"total"`
+	}{
+		Invites: invs,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
+
+func getInvite(c echo.Context) error {
+	err, inv := database.SelectInvite(c.Param("code"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	rs, err := inv.SelectRedemptions()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		*database.Invite
+		Redemptions []database.Redemption `json:"redemptions"`
+	}{
+		Invite:      inv,
+		Redemptions: rs,
+	})
+}
+
+func revokeInvite(c echo.Context) error {
+	switch err := database.RevokeInvite(c.Param("code")); err {
+	case nil:
+		return c.NoContent(http.StatusNoContent)
+	case database.ErrInviteNotFound:
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	case database.ErrInviteBurned:
+		return c.JSON(http.StatusConflict, &struct {
+			Message string
+		}{
+			Message: "Invite code was already used and cannot be revoked."})
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+}
+
+// pagination reads the page and per_page query parameters, falling back to
+// the first page of defaultPageSize entries.
+func pagination(c echo.Context) (uint, uint) {
+	page, err := strconv.ParseUint(c.QueryParam("page"), 10, 32)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.ParseUint(c.QueryParam("per_page"), 10, 32)
+	if err != nil || perPage < 1 {
+		perPage = defaultPageSize
+	}
+	if perPage > maxPageSize {
+		perPage = maxPageSize
+	}
+	return uint(page), uint(perPage)
+}
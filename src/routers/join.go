@@ -1,13 +1,26 @@
 package routers
 
 import (
+	"html"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/jobs"
+	mailer "github.com/orchestrafm/profiles/src/mail"
 	"github.com/spidernest-go/logger"
 	"github.com/spidernest-go/mux"
 )
 
+const maxEmailLength = 254
+
+// resendInterval is how long an unconfirmed address waits before another
+// confirmation mail is sent to it.
+const resendInterval = 15 * time.Minute
+
 func joinMailingList(c echo.Context) error {
 	rq := new(database.ReqList)
 	if err := c.Bind(rq); err != nil {
@@ -20,17 +33,144 @@ func joinMailingList(c echo.Context) error {
 		}{
 			Message: "Email was invalid or malformed."})
 	}
+	email := strings.TrimSpace(rq.Email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > maxEmailLength {
+		return c.JSON(http.StatusNotAcceptable, &struct {
+			Message string
+		}{
+			Message: "Email was invalid or malformed."})
+	}
 
-	err := rq.New()
+	// the entry only becomes active once the owner of the address confirms
+	// it, the response is the same whether or not it was already listed
+	err, rq := database.SelectReqList(email)
 	if err != nil {
-		return c.JSON(http.StatusNotAcceptable, &struct {
+		rq = &database.ReqList{Email: email}
+		if err := rq.New(); err != nil {
+			return c.JSON(http.StatusNotAcceptable, &struct {
+				Message string
+			}{
+				Message: "Email did not get submitted to the database."})
+		}
+	}
+	if rq.Confirmed == nil {
+		last, err := database.LastConsent(email, database.ConsentRequested)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, &struct {
+				Message string
+			}{
+				Message: "Database could not be reached."})
+		}
+		if time.Since(last) < resendInterval {
+			// repeating the request mustn't flood the address with mail
+			return c.JSON(http.StatusOK, &struct {
+				Message string
+			}{
+				Message: "Check your inbox to confirm your email."})
+		}
+
+		database.RecordConsent(email, database.ConsentRequested, c.RealIP())
+		if err := jobs.SendConfirmation(rq); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Confirmation mail could not be sent.")
+
+			return c.JSON(http.StatusServiceUnavailable, &struct {
+				Message string
+			}{
+				Message: "Confirmation mail could not be sent, try again later."})
+		}
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Message string
+	}{
+		Message: "Check your inbox to confirm your email."})
+}
+
+func confirmMailingList(c echo.Context) error {
+	email, err := mailer.VerifyToken(mailer.PurposeConfirm, c.QueryParam("token"))
+	switch err {
+	case nil:
+	case mailer.ErrTokenExpired:
+		return c.JSON(http.StatusGone, &struct {
 			Message string
 		}{
-			Message: "Email did not get submitted to the database."})
+			Message: "Confirmation link has expired, join the waitlist again."})
+	default:
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "Confirmation link is invalid."})
+	}
+
+	err, rq := database.SelectReqList(email)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	ok, err := rq.Confirm()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	if ok {
+		database.RecordConsent(email, database.ConsentConfirmed, c.RealIP())
+	}
+
+	pos, _ := database.WaitlistPosition(email)
+	return c.JSON(http.StatusOK, &struct {
+		Message  string
+		Position uint64 `json:"position,omitempty"`
+	}{
+		Message:  "Email confirmed.",
+		Position: pos})
+}
+
+// confirmUnsubscribe only asks for confirmation, so link scanners following
+// the unsubscribe link don't remove anyone.
+func confirmUnsubscribe(c echo.Context) error {
+	token := c.QueryParam("token")
+	if _, err := mailer.VerifyToken(mailer.PurposeUnsubscribe, token); err != nil {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "Unsubscribe link is invalid."})
+	}
+
+	return c.HTML(http.StatusOK, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head><body>
+<form method="post" action="unsubscribe?token=`+html.EscapeString(url.QueryEscape(token))+`">
+<p>Remove your email from the Orchestra FM mailing list?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body></html>`)
+}
+
+// unsubscribeMailingList removes the entry, it is what the confirmation form
+// and one-click List-Unsubscribe-Post requests send.
+func unsubscribeMailingList(c echo.Context) error {
+	email, err := mailer.VerifyToken(mailer.PurposeUnsubscribe, c.QueryParam("token"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "Unsubscribe link is invalid."})
+	}
+
+	if err, _ := database.SelectReqList(email); err == nil {
+		if err := database.Remove(email); err != nil {
+			return c.JSON(http.StatusInternalServerError, &struct {
+				Message string
+			}{
+				Message: "Database could not be reached."})
+		}
+		database.RecordConsent(email, database.ConsentWithdrawn, c.RealIP())
 	}
 
 	return c.JSON(http.StatusOK, &struct {
 		Message string
 	}{
-		Message: "OK."})
+		Message: "Email was unsubscribed."})
 }
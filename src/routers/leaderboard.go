@@ -0,0 +1,134 @@
+package routers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/mux"
+)
+
+const defaultSort = "performance_rating"
+
+var (
+	errUnknownCountry = errors.New("country must be an ISO 3166-1 alpha-2 code.")
+	errUnknownScope   = errors.New("scope must be global or friends.")
+	errNoProfile      = errors.New("Caller does not have a profile.")
+)
+
+// board reads the sort, country and scope query parameters.
+func board(c echo.Context) (*database.Board, string, error) {
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = defaultSort
+	}
+	b, err := database.NewBoard(sort)
+	if err != nil {
+		return nil, sort, err
+	}
+
+	if q := c.QueryParam("country"); q != "" {
+		country := strings.ToUpper(q)
+		if !database.ValidCountry(country) {
+			return nil, sort, errUnknownCountry
+		}
+		b.InCountry(country)
+	}
+	switch c.QueryParam("scope") {
+	case "", "global":
+	case "friends":
+		err, pf := database.SelectProfileByUUID(caller(c).Subject)
+		if err != nil {
+			return nil, sort, errNoProfile
+		}
+		b.FollowedBy(pf.ID)
+	default:
+		return nil, sort, errUnknownScope
+	}
+	b.HiddenFrom(viewer(c))
+	return b, sort, nil
+}
+
+func getLeaderboard(c echo.Context) error {
+	b, sort, err := board(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	}
+
+	var after *database.Cursor
+	if q := c.QueryParam("cursor"); q != "" {
+		if after, err = database.ParseCursor(q); err != nil {
+			return c.JSON(http.StatusBadRequest, &struct {
+				Message string
+			}{
+				Message: "cursor is invalid."})
+		}
+	}
+	// the cursor takes the place of page, per_page is shared with every other list
+	_, perPage := pagination(c)
+
+	ss, err := b.Page(after, perPage)
+	switch err {
+	case nil:
+	case database.ErrInvalidCursor:
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "cursor is invalid."})
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	next := ""
+	if uint(len(ss)) == perPage {
+		next = b.Next(&ss[len(ss)-1]).String()
+	}
+	return c.JSON(http.StatusOK, &struct {
+		Sort    string              `json:"sort"`
+		Entries []database.Standing `json:"entries"`
+		PerPage uint                `json:"per_page"`
+		Next    string              `json:"next_cursor,omitempty"`
+	}{
+		Sort:    sort,
+		Entries: ss,
+		PerPage: perPage,
+		Next:    next,
+	})
+}
+
+func getMyStanding(c echo.Context) error {
+	b, _, err := board(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: err.Error()})
+	}
+	err, pf := database.SelectProfileByUUID(caller(c).Subject)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	s, err := b.Standing(pf.ID)
+	switch err {
+	case nil:
+		return c.JSON(http.StatusOK, s)
+	case database.ErrNotRanked:
+		return c.JSON(http.StatusNotFound, &struct {
+			Message string
+		}{
+			Message: "Profile is not on the leaderboard until it has played."})
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+}
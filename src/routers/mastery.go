@@ -0,0 +1,46 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/mux"
+)
+
+func getMastery(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if _, err := viewable(c, id); err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	mode := strings.ToLower(c.QueryParam("mode"))
+	if mode != "" && !database.ValidMode(mode) {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "mode must be 4k, 5k or 7k."})
+	}
+
+	m, tiers, err := database.SelectMastery(id, mode)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Mode    string               `json:"mode,omitempty"`
+		Mastery uint8                `json:"mastery"`
+		Tiers   []database.TierStats `json:"tiers"`
+	}{
+		Mode:    mode,
+		Mastery: m,
+		Tiers:   tiers,
+	})
+}
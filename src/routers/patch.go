@@ -0,0 +1,109 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+func editProfile(c echo.Context) error {
+	i, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msgf("Passed id parameter (%s) was not a valid number", c.Param("id"))
+
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	// Clients must say which version of the profile their edit is based on
+	match := strings.TrimSpace(string(c.Request().Request.Header.Peek("If-Match")))
+	if match == "" {
+		return c.JSON(http.StatusPreconditionRequired, &struct {
+			Message string
+		}{
+			Message: "If-Match header with the profile's ETag is required."})
+	}
+	versions, wildcard, ok := parseETags(match)
+	if !ok {
+		return c.JSON(http.StatusPreconditionFailed, &struct {
+			Message string
+		}{
+			Message: "If-Match header does not hold a valid ETag."})
+	}
+
+	// Validate Data
+	edit := new(database.ProfileEdit)
+	if err := c.Bind(edit); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invalid or malformed profile edit.")
+
+		return c.JSON(http.StatusNotAcceptable, &struct {
+			Message string
+		}{
+			Message: "Profile edit data was invalid or malformed."})
+	}
+	if errs := edit.Validate(); len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+			Errors  map[string]string
+		}{
+			Message: "One or more fields are invalid.",
+			Errors:  errs})
+	}
+
+	err, pf := database.SelectProfileById(i)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	// the edit still only applies to the version that was matched
+	err = database.ErrVersionMismatch
+	if wildcard || versions[pf.Version] {
+		err = pf.Edit(edit, pf.Version)
+	}
+	if err == database.ErrVersionMismatch {
+		return c.JSON(http.StatusPreconditionFailed, &struct {
+			Message string
+		}{
+			Message: "Profile was changed since it was last fetched."})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return showProfile(c, pf)
+}
+
+// parseETags reads the list of entity tags in an If-Match header, wildcard is
+// set by "*". Tags are compared by their value whether they are weak or
+// not, those that aren't a profile version never match.
+func parseETags(h string) (versions map[uint64]bool, wildcard bool, ok bool) {
+	versions = map[uint64]bool{}
+	for _, tag := range strings.Split(h, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			wildcard = true
+			continue
+		}
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, false, false
+		}
+		tag = tag[1 : len(tag)-1]
+		if strings.ContainsRune(tag, '"') {
+			return nil, false, false
+		}
+		if v, err := strconv.ParseUint(tag, 10, 64); err == nil {
+			versions[v] = true
+		}
+	}
+	return versions, wildcard, true
+}
@@ -0,0 +1,106 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/orchestrafm/profiles/src/achievements"
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/jobs"
+	"github.com/orchestrafm/profiles/src/progression"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+func recordPlay(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	pl := new(database.Play)
+	if err := c.Bind(pl); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invalid or malformed play.")
+
+		return c.JSON(http.StatusNotAcceptable, &struct {
+			Message string
+		}{
+			Message: "Play was invalid or malformed."})
+	}
+	if errs := pl.Validate(); len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+			Errors  map[string]string
+		}{
+			Message: "One or more fields are invalid.",
+			Errors:  errs})
+	}
+
+	pf, err := database.RecordPlay(id, pl)
+	switch err {
+	case nil:
+	case database.ErrProfileNotFound:
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	// the play is already stored, so failing to evaluate achievements only
+	// delays them until the next one
+	earned, _ := jobs.UnlockAchievements(pf)
+
+	pf.Progress = progression.Active.Progress(pf.Experience)
+	pf.ModeStats.Progress = progression.Active.Progress(pf.ModeStats.Experience)
+	pf.UUID = ""
+	return c.JSON(http.StatusCreated, &struct {
+		*database.Profile
+		Unlocked []achievements.Achievement `json:"achievements_unlocked,omitempty"`
+	}{
+		Profile:  pf,
+		Unlocked: earned,
+	})
+}
+
+func listRecentPlays(c echo.Context) error {
+	return listPlays(c, database.SelectRecentPlays)
+}
+
+func listBestPlays(c echo.Context) error {
+	return listPlays(c, database.SelectBestPlays)
+}
+
+func listPlays(c echo.Context, sel func(uint64, uint, uint) ([]database.Play, uint64, error)) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if _, err := viewable(c, id); err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	page, perPage := pagination(c)
+
+	pls, total, err := sel(id, page, perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Plays   []database.Play `json:"plays"`
+		Page    uint            `json:"page"`
+		PerPage uint            `json:"per_page"`
+		Total   uint64          `json:"total"`
+	}{
+		Plays:   pls,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
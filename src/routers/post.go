@@ -2,6 +2,7 @@ package routers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/orchestrafm/profiles/src/database"
 	"github.com/orchestrafm/profiles/src/identity"
@@ -23,13 +24,39 @@ func createProfile(c echo.Context) error {
 			Message: "Registration form data was invalid or malformed."})
 	}
 
-	// Burn Invite Code and reject if already burned
-	err := database.BurnInvite(reg.InviteCode)
+	// the account keeps the casing given as its display name
+	reg.Username = strings.TrimSpace(reg.Username)
+	name := strings.ToLower(reg.Username)
+	if !database.ValidUsername(name) {
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+			Errors  map[string]string
+		}{
+			Message: "One or more fields are invalid.",
+			Errors: map[string]string{
+				"username": "Username must be 3 to 32 letters, digits, dots, dashes or underscores."}})
+	}
+
+	// Names other profiles recently gave up are held back for them
+	if taken, err := database.NameReserved(name, 0); err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	} else if taken {
+		return c.JSON(http.StatusConflict, &struct {
+			Message string
+		}{
+			Message: database.ErrUsernameTaken.Error()})
+	}
+
+	// Burn Invite Code and reject if already burned or expired
+	inv, err := database.BurnInvite(reg.InviteCode)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, &struct {
 			Message string
 		}{
-			Message: "Invite Code is invalid or already used."})
+			Message: "Invite Code is invalid, expired or already used."})
 	}
 
 	// Setup Profile
@@ -53,6 +80,7 @@ func createProfile(c echo.Context) error {
 	}
 	p := new(database.Profile)
 	p.UUID = uuid
+	p.Handle = name
 	err = p.New()
 	if err != nil {
 		logger.Error().
@@ -79,6 +107,9 @@ func createProfile(c echo.Context) error {
 			Message: "Database could not be reached."})
 	}
 
+	// losing track of who redeemed the code doesn't warrant failing the registration
+	inv.Redeem(p.ID)
+
 	return c.JSON(http.StatusOK, p)
 }
 
@@ -0,0 +1,40 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/orchestrafm/profiles/src/jobs"
+	"github.com/spidernest-go/mux"
+)
+
+func recomputeLevels(c echo.Context) error {
+	n, err := jobs.RecomputeLevels()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Updated uint64 `json:"updated"`
+	}{
+		Updated: n,
+	})
+}
+
+func recomputePerformance(c echo.Context) error {
+	n, err := jobs.RecomputePerformance()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Updated uint64 `json:"updated"`
+	}{
+		Updated: n,
+	})
+}
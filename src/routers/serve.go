@@ -6,6 +6,8 @@ import (
 
 	"github.com/emirpasic/gods/lists/arraylist"
 	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/media"
+	"github.com/orchestrafm/profiles/src/policy"
 	"github.com/spidernest-go/logger"
 	"github.com/spidernest-go/mux"
 	"github.com/spidernest-go/mux/middleware"
@@ -36,23 +38,80 @@ func ListenAndServe() {
 	r = echo.New()
 
 	r.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "If-Match"},
+		ExposeHeaders: []string{"ETag"},
 	}), middleware.Recover())
 
+	if l, ok := media.Store.(*media.LocalStorage); ok {
+		r.Static("/media", l.Dir)
+	}
+
 	v0 := r.Group("/api/v0")
 
-	v0.GET("/oidc/authorize", getOIDCLogin)
-	v0.GET("/oidc/callback", getOIDCRedirect)
+	if identity.Driver() == identity.DriverKeycloak {
+		v0.GET("/oidc/authorize", getOIDCLogin)
+		v0.GET("/oidc/callback", getOIDCRedirect)
+	}
 
 	v0.POST("/authorize/basic", loginProfile)
 	v0.POST("/authorize/refresh", refreshAuth)
 
-	v0.GET("/profile/:id", getProfileById)
+	v0.GET("/me", getMe, authenticate)
+	v0.POST("/me/export", requestExport, authenticate)
+	v0.GET("/me/blocks", listBlocked, authenticate)
+	v0.GET("/export/:token", downloadExport)
+	v0.GET("/profile/:id", getProfileById, authenticate)
+	v0.GET("/profile/uuid/:uuid", getProfileByUUID, authenticate)
+	v0.GET("/profile/name/:username", getProfileByName, authenticate)
 	v0.POST("/profile", createProfile)
+	v0.PATCH("/profile/:id", editProfile, authenticate,
+		policy.Require(policy.AnyOf(policy.Owner(), policy.Scope("profile:admin")), profileOwner))
+	v0.DELETE("/profile/:id", deleteProfile, authenticate,
+		policy.Require(policy.AnyOf(policy.Owner(), policy.Scope("profile:admin")), profileOwner))
+	v0.PUT("/profile/:id/username", renameProfile, authenticate,
+		policy.Require(policy.AnyOf(policy.Owner(), policy.Scope("profile:admin")), profileOwner))
+	v0.GET("/profile/:id/usernames", listPreviousNames, authenticate)
+	v0.PUT("/profile/:id/avatar", putAvatar, authenticate,
+		policy.Require(policy.AnyOf(policy.Owner(), policy.Scope("profile:admin")), profileOwner))
+	v0.PUT("/profile/:id/banner", putBanner, authenticate,
+		policy.Require(policy.AnyOf(policy.Owner(), policy.Scope("profile:admin")), profileOwner))
+	v0.POST("/profile/:id/plays", recordPlay, authenticate, policy.Require(policy.Scope("score:write"), nil))
+	v0.GET("/profile/:id/plays/recent", listRecentPlays, authenticate)
+	v0.GET("/profile/:id/plays/best", listBestPlays, authenticate)
+	v0.GET("/profile/:id/mastery", getMastery, authenticate)
+	v0.GET("/profile/:id/history", getHistory, authenticate)
+	v0.GET("/profile/:id/achievements", getAchievements, authenticate)
+	v0.PUT("/profile/:id/follow", followProfile, authenticate)
+	v0.DELETE("/profile/:id/follow", unfollowProfile, authenticate)
+	v0.GET("/profile/:id/followers", listFollowers, authenticate)
+	v0.GET("/profile/:id/following", listFollowing, authenticate)
+	v0.GET("/profile/:id/friends", listFriends, authenticate)
+	v0.PUT("/profile/:id/block", blockProfile, authenticate)
+	v0.DELETE("/profile/:id/block", unblockProfile, authenticate)
+
+	v0.GET("/leaderboard", getLeaderboard, authenticate)
+	v0.GET("/leaderboard/me", getMyStanding, authenticate)
+
+	v0.POST("/levels/recompute", recomputeLevels, authenticate, policy.Require(policy.Scope("profile:admin"), nil))
+	v0.POST("/performance/recompute", recomputePerformance, authenticate, policy.Require(policy.Scope("profile:admin"), nil))
 
 	v0.POST("/invite/join", joinMailingList)
+	v0.GET("/invite/confirm", confirmMailingList)
+	v0.GET("/invite/unsubscribe", confirmUnsubscribe)
+	v0.POST("/invite/unsubscribe", unsubscribeMailingList)
+
+	invites := v0.Group("/invites", authenticate, policy.Require(policy.Scope("invite:admin"), nil))
+	invites.POST("", mintInvites)
+	invites.GET("", listInvites)
+	invites.GET("/:code", getInvite)
+	invites.DELETE("/:code", revokeInvite)
+
+	waitlist := v0.Group("/waitlist", authenticate, policy.Require(policy.Scope("invite:admin"), nil))
+	waitlist.GET("", getWaitlist)
+	waitlist.GET("/position", getWaitlistPosition)
+	waitlist.POST("/invite", inviteWaitlist)
 
 	r.Start(":5000")
 }
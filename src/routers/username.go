@@ -0,0 +1,163 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/identity"
+	"github.com/orchestrafm/profiles/src/policy"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+// renameProfile changes the profile's username. Owners have to wait out the
+// cooldown between renames, admins renaming someone else's profile don't
+// and the name they replace can't be taken or resolved again.
+func renameProfile(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	err, pf := database.SelectProfileById(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	body := new(struct {
+		Username string `json:"username"`
+	})
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusNotAcceptable, &struct {
+			Message string
+		}{
+			Message: "Rename was invalid or malformed."})
+	}
+	// the casing given is kept for the account's display name
+	display := strings.TrimSpace(body.Username)
+	name := strings.ToLower(display)
+	if !database.ValidUsername(name) {
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+			Errors  map[string]string
+		}{
+			Message: "One or more fields are invalid.",
+			Errors: map[string]string{
+				"username": "Username must be 3 to 32 letters, digits, dots, dashes or underscores."}})
+	}
+
+	acc, err := identity.GetAccount(pf.UUID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Identity Server could not be reached."})
+	}
+	old, oldDisplay := strings.ToLower(acc.Username), acc.Username
+	if strings.EqualFold(acc.FirstName, acc.Username) {
+		oldDisplay = acc.FirstName
+	}
+	if name == old {
+		return c.JSON(http.StatusBadRequest, &struct {
+			Message string
+		}{
+			Message: "Username is unchanged."})
+	}
+
+	t, _ := c.Get(policy.TokenKey).(*policy.Token)
+	forced := t == nil || t.Subject != pf.UUID
+	if !forced {
+		last, err := database.LastRename(pf.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, &struct {
+				Message string
+			}{
+				Message: "Database could not be reached."})
+		}
+		if next := last.Add(database.RenameCooldown); next.After(time.Now()) {
+			return c.JSON(http.StatusTooManyRequests, &struct {
+				Message string
+				Next    time.Time `json:"next"`
+			}{
+				Message: "Username was changed too recently.",
+				Next:    next})
+		}
+	}
+
+	switch taken, err := database.NameReserved(name, pf.ID); {
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	case taken:
+		return c.JSON(http.StatusConflict, &struct {
+			Message string
+		}{
+			Message: database.ErrUsernameTaken.Error()})
+	}
+
+	switch err := identity.RenameAccount(pf.UUID, display); err {
+	case nil:
+	case identity.ErrAccountExists:
+		return c.JSON(http.StatusConflict, &struct {
+			Message string
+		}{
+			Message: database.ErrUsernameTaken.Error()})
+	default:
+		logger.Error().
+			Err(err).
+			Msgf("Identity Provider refused to rename the account of profile %d.", pf.ID)
+
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Identity Server failed to rename the account."})
+	}
+
+	if err := pf.Rename(old, name, forced); err != nil {
+		// keep both sides agreeing on the old name
+		if err := identity.RenameAccount(pf.UUID, oldDisplay); err != nil {
+			logger.Error().
+				Err(err).
+				Msgf("Account of profile %d could not be renamed back.", pf.ID)
+		}
+		if err == database.ErrUsernameTaken {
+			return c.JSON(http.StatusConflict, &struct {
+				Message string
+			}{
+				Message: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return showProfile(c, pf)
+}
+
+func listPreviousNames(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+	if _, err := viewable(c, id); err != nil {
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	}
+
+	ns, err := database.SelectPreviousNames(id, false)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	return c.JSON(http.StatusOK, &struct {
+		Usernames []database.PreviousName `json:"usernames"`
+	}{
+		Usernames: ns,
+	})
+}
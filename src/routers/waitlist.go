@@ -0,0 +1,87 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/orchestrafm/profiles/src/database"
+	"github.com/orchestrafm/profiles/src/jobs"
+	"github.com/spidernest-go/logger"
+	"github.com/spidernest-go/mux"
+)
+
+func getWaitlist(c echo.Context) error {
+	n, err := database.WaitlistSize()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Size uint64 `json:"size"`
+	}{
+		Size: n,
+	})
+}
+
+func getWaitlistPosition(c echo.Context) error {
+	n, err := database.WaitlistPosition(c.QueryParam("email"))
+	switch err {
+	case nil:
+	case database.ErrNotWaitlisted:
+		return c.JSON(http.StatusNotFound, ErrGeneric)
+	default:
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+
+	return c.JSON(http.StatusOK, &struct {
+		Position uint64 `json:"position"`
+	}{
+		Position: n,
+	})
+}
+
+func inviteWaitlist(c echo.Context) error {
+	req := new(struct {
+		Count int `json:"count"`
+	})
+	if err := c.Bind(req); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Invalid or malformed waitlist request.")
+
+		return c.JSON(http.StatusNotAcceptable, &struct {
+			Message string
+		}{
+			Message: "Waitlist request was invalid or malformed."})
+	}
+	if req.Count < 1 || req.Count > maxInviteBatch {
+		return c.JSON(http.StatusUnprocessableEntity, &struct {
+			Message string
+			Errors  map[string]string
+		}{
+			Message: "One or more fields are invalid.",
+			Errors:  map[string]string{"count": "Between 1 and 100 entries can be invited at once."}})
+	}
+
+	rs, err := jobs.InviteWaitlist(req.Count)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &struct {
+			Message string
+		}{
+			Message: "Database could not be reached."})
+	}
+	n, _ := database.WaitlistSize()
+
+	return c.JSON(http.StatusOK, &struct {
+		Invited   []database.ReqList `json:"invited"`
+		Remaining uint64             `json:"remaining"`
+	}{
+		Invited:   rs,
+		Remaining: n,
+	})
+}